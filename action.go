@@ -0,0 +1,88 @@
+package boohu
+
+import "errors"
+
+type actionKind int
+
+const (
+	MoveAction actionKind = iota
+	WaitAction
+	RestAction
+	DescendAction
+	QuaffAction
+	ThrowAction
+	EvokeAction
+	EquipAction
+	AutoexploreAction
+	TravelAction
+)
+
+// Action is a command for the player's turn. Actions are executed by Do,
+// whether they come from keys, replays or bots. Only the fields relevant to
+// the kind of action are used: Dir for moves, Potion for quaffing,
+// Projectile and Target for throwing, Rod and Target for evoking, and Target
+// for travelling.
+type Action struct {
+	Kind       actionKind
+	Dir        direction
+	Potion     potion
+	Projectile projectile
+	Rod        rod
+	Target     Position
+}
+
+// Do performs action a during the player's turn ev. A non-nil error
+// explains why the action could not be done, in which case the turn has not
+// been spent.
+func (g *Game) Do(a Action, ev Event) error {
+	switch a.Kind {
+	case ThrowAction, EvokeAction, TravelAction:
+		if !g.Dungeon.Valid(a.Target) {
+			return errors.New("Invalid target position.")
+		}
+	}
+	var err error
+	switch a.Kind {
+	case MoveAction:
+		err = g.MovePlayer(g.Player.Pos.To(a.Dir), ev)
+	case WaitAction:
+		g.WaitTurn(ev)
+	case RestAction:
+		err = g.Rest(ev)
+	case DescendAction:
+		if !g.Stairs[g.Player.Pos] {
+			return errors.New("No stairs here.")
+		}
+		g.Descend(ev)
+	case QuaffAction:
+		err = a.Potion.Use(g, ev)
+	case ThrowAction:
+		if g.Player.Consumables[a.Projectile] <= 0 {
+			return errors.New("You do not have any " + a.Projectile.Plural() + ".")
+		}
+		err = a.Projectile.Targetter().Action(g, a.Target)
+		if err == nil {
+			err = a.Projectile.Use(g, ev)
+		}
+	case EvokeAction:
+		err = a.Rod.CanEvoke(g)
+		if err != nil {
+			return err
+		}
+		if targ := a.Rod.Targetter(); targ != nil {
+			err = targ.Action(g, a.Target)
+		}
+		if err == nil {
+			err = a.Rod.Use(g, ev)
+		}
+	case EquipAction:
+		err = g.Equip(ev)
+	case AutoexploreAction:
+		err = g.Autoexplore(ev)
+	case TravelAction:
+		err = g.Travel(a.Target, ev)
+	default:
+		err = errors.New("Unknown action.")
+	}
+	return err
+}
@@ -1,4 +1,4 @@
-package main
+package boohu
 
 type aptitude int
 
@@ -49,7 +49,7 @@ func (ap aptitude) String() string {
 	return text
 }
 
-func (g *game) RandomApt() (aptitude, bool) {
+func (g *Game) RandomApt() (aptitude, bool) {
 	// XXX use less uniform probability ?
 	max := int(AptStrong)
 	count := 0
@@ -59,7 +59,7 @@ func (g *game) RandomApt() (aptitude, bool) {
 		if count > 1000 {
 			break
 		}
-		r := RandInt(max + 1)
+		r := g.Rand.Int(max + 1)
 		apt = aptitude(r)
 		if g.Player.Aptitudes[apt] {
 			continue
@@ -69,7 +69,7 @@ func (g *game) RandomApt() (aptitude, bool) {
 	return apt, false
 }
 
-func (g *game) ApplyAptitude(ap aptitude) {
+func (g *Game) ApplyAptitude(ap aptitude) {
 	if g.Player.Aptitudes[ap] {
 		// should not happen
 		g.Print("Hm… You already have that aptitude. " + ap.String())
@@ -21,25 +21,25 @@
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 // SOFTWARE.
 
-package main
+package boohu
 
 import (
 	"container/heap"
 )
 
 type node struct {
-	Pos    position
+	Pos    Position
 	Cost   int
 	Rank   int
-	Parent *position
+	Parent *Position
 	Open   bool
 	Closed bool
 	Index  int
 }
 
-type nodeMap map[position]*node
+type nodeMap map[Position]*node
 
-func (nm nodeMap) get(p position) *node {
+func (nm nodeMap) get(p Position) *node {
 	n, ok := nm[p]
 	if !ok {
 		n = &node{Pos: p}
@@ -49,12 +49,12 @@ func (nm nodeMap) get(p position) *node {
 }
 
 type Astar interface {
-	Neighbors(position) []position
-	Cost(position, position) int
-	Estimation(position, position) int
+	Neighbors(Position) []Position
+	Cost(Position, Position) int
+	Estimation(Position, Position) int
 }
 
-func AstarPath(ast Astar, from, to position) (path []position, length int, found bool) {
+func AstarPath(ast Astar, from, to Position) (path []Position, length int, found bool) {
 	nm := nodeMap{}
 	nq := &priorityQueue{}
 	heap.Init(nq)
@@ -72,7 +72,7 @@ func AstarPath(ast Astar, from, to position) (path []position, length int, found
 
 		if current.Pos == to {
 			// Found a path to the goal.
-			p := []position{}
+			p := []Position{}
 			curr := current
 			for {
 				p = append(p, curr.Pos)
@@ -1,8 +1,8 @@
-package main
+package boohu
 
 import "errors"
 
-func (g *game) Autoexplore(ev event) error {
+func (g *Game) Autoexplore(ev Event) error {
 	if mons := g.MonsterInLOS(); mons.Exists() {
 		return errors.New("You cannot auto-explore while there are monsters in view.")
 	}
@@ -23,8 +23,8 @@ func (g *game) Autoexplore(ev event) error {
 	return g.MovePlayer(n.Pos, ev)
 }
 
-func (g *game) AutoexploreSources() []position {
-	sources := []position{}
+func (g *Game) AutoexploreSources() []Position {
+	sources := []Position{}
 	np := &normalPath{game: g}
 	for i, c := range g.Dungeon.Cells {
 		pos := g.Dungeon.CellPosition(i)
@@ -43,12 +43,12 @@ func (g *game) AutoexploreSources() []position {
 	return sources
 }
 
-func (g *game) BuildAutoexploreMap(sources []position) {
+func (g *Game) BuildAutoexploreMap(sources []Position) {
 	ap := &autoexplorePath{game: g}
 	g.AutoexploreMap = Dijkstra(ap, sources, 9999)
 }
 
-func (g *game) NextAuto() (*node, bool) {
+func (g *Game) NextAuto() (*node, bool) {
 	rebuild := false
 	ap := &autoexplorePath{game: g}
 	neighbors := ap.Neighbors(g.Player.Pos)
@@ -0,0 +1,239 @@
+package boohu
+
+import "sort"
+
+// Bot chooses the player's actions in games played without any user
+// interface.
+type Bot interface {
+	// Act returns the action for the player's turn. If the action fails,
+	// Act is called again during the same turn, with the error available
+	// from the view.
+	Act(v *View) Action
+}
+
+// View is a read-only view of a game, as seen by the player.
+type View struct {
+	g        *Game
+	err      error
+	failures int
+}
+
+func (v *View) Pos() Position {
+	return v.g.Player.Pos
+}
+
+func (v *View) HP() int {
+	return v.g.Player.HP
+}
+
+func (v *View) HPMax() int {
+	return v.g.Player.HPMax()
+}
+
+func (v *View) MP() int {
+	return v.g.Player.MP
+}
+
+func (v *View) MPMax() int {
+	return v.g.Player.MPMax()
+}
+
+func (v *View) Depth() int {
+	return v.g.Depth
+}
+
+func (v *View) Turn() int {
+	return v.g.Turn
+}
+
+func (v *View) HasStatus(st status) bool {
+	return v.g.Player.HasStatus(st)
+}
+
+// Potions returns how many potions of kind p the player has.
+func (v *View) Potions(p potion) int {
+	return v.g.Player.Consumables[p]
+}
+
+// Projectiles returns how many projectiles of kind p the player has.
+func (v *View) Projectiles(p projectile) int {
+	return v.g.Player.Consumables[p]
+}
+
+func (v *View) Rods() []rod {
+	return v.g.SortedRods()
+}
+
+func (v *View) OnStairs() bool {
+	return v.g.Stairs[v.g.Player.Pos]
+}
+
+// Stairs returns the explored stairs, closest first.
+func (v *View) Stairs() []Position {
+	ps := []Position{}
+	for i := range v.g.Dungeon.Cells {
+		pos := v.g.Dungeon.CellPosition(i)
+		if v.g.Stairs[pos] && v.g.Dungeon.Cell(pos).Explored {
+			ps = append(ps, pos)
+		}
+	}
+	v.sortByDistance(ps)
+	return ps
+}
+
+// Monsters returns the positions of the monsters in view, closest first.
+func (v *View) Monsters() []Position {
+	ps := []Position{}
+	for _, mons := range v.g.Monsters {
+		if mons.Exists() && v.g.Player.LOS[mons.Pos] {
+			ps = append(ps, mons.Pos)
+		}
+	}
+	v.sortByDistance(ps)
+	return ps
+}
+
+// MonsterKind returns the kind of the monster in view at pos.
+func (v *View) MonsterKind(pos Position) (monsterKind, bool) {
+	if !v.g.Player.LOS[pos] {
+		return 0, false
+	}
+	mons := v.g.MonsterAt(pos)
+	if !mons.Exists() {
+		return 0, false
+	}
+	return mons.Kind, true
+}
+
+// NextStep returns the direction of the first step of a path to pos going
+// through explored cells.
+func (v *View) NextStep(pos Position) (direction, bool) {
+	path := v.g.PlayerPath(v.g.Player.Pos, pos)
+	if len(path) < 2 {
+		return 0, false
+	}
+	return path[len(path)-2].Dir(v.g.Player.Pos), true
+}
+
+// Err returns the error of the last failed action of the current turn.
+func (v *View) Err() error {
+	return v.err
+}
+
+// Failures returns the number of failed actions during the current turn.
+func (v *View) Failures() int {
+	return v.failures
+}
+
+func (v *View) sortByDistance(ps []Position) {
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].Distance(v.g.Player.Pos) < ps[j].Distance(v.g.Player.Pos)
+	})
+}
+
+// BaselineBot explores the dungeon, fights the monsters it sees, drinks
+// potions of heal wounds when its HP are low and descends when there is
+// nothing left to explore.
+type BaselineBot struct{}
+
+func (b BaselineBot) Act(v *View) Action {
+	candidates := []Action{}
+	if v.HP() < v.HPMax()/3 && v.Potions(HealWoundsPotion) > 0 {
+		candidates = append(candidates, Action{Kind: QuaffAction, Potion: HealWoundsPotion})
+	}
+	if ms := v.Monsters(); len(ms) > 0 {
+		if dir, ok := v.NextStep(ms[0]); ok {
+			candidates = append(candidates, Action{Kind: MoveAction, Dir: dir})
+		}
+		candidates = append(candidates, Action{Kind: MoveAction, Dir: ms[0].Dir(v.Pos())})
+	} else {
+		candidates = append(candidates, Action{Kind: RestAction}, Action{Kind: AutoexploreAction})
+		if v.OnStairs() {
+			candidates = append(candidates, Action{Kind: DescendAction})
+		}
+		for _, pos := range v.Stairs() {
+			candidates = append(candidates, Action{Kind: TravelAction, Target: pos})
+		}
+	}
+	if v.Failures() < len(candidates) {
+		return candidates[v.Failures()]
+	}
+	return Action{Kind: WaitAction}
+}
+
+// maxBotFailures is the number of failed actions after which the bot's
+// player waits for a turn.
+const maxBotFailures = 10
+
+// BotResult summarizes a game played by a bot.
+type BotResult struct {
+	Seed    int64
+	Outcome outcome
+	Depth   int
+	Turns   int
+	Killer  string
+}
+
+// botUI is a Renderer that asks a bot for the player's actions.
+type botUI struct {
+	bot      Bot
+	maxTurns int
+	outcome  outcome
+}
+
+// PlayBot plays a game with the given seed using bot b. The game is
+// stopped after maxTurns turns if it did not end before. No files are
+// written.
+func PlayBot(b Bot, seed int64, maxTurns int) BotResult {
+	ui := &botUI{bot: b, maxTurns: maxTurns}
+	g := &Game{noSave: true, ui: ui}
+	g.SetSeed(seed)
+	g.InitLevel()
+	g.EventLoop()
+	return BotResult{
+		Seed:    seed,
+		Outcome: ui.outcome,
+		Depth:   g.Depth,
+		Turns:   g.Turn / 10,
+		Killer:  g.Killer,
+	}
+}
+
+func (ui *botUI) ExploreStep(g *Game) bool {
+	return false
+}
+
+func (ui *botUI) RestStep(g *Game) bool {
+	return false
+}
+
+func (ui *botUI) HandlePlayerTurn(g *Game, ev Event) bool {
+	if g.Turn/10 >= ui.maxTurns {
+		return true
+	}
+	v := &View{g: g}
+	for {
+		a := Action{Kind: WaitAction}
+		if v.failures < maxBotFailures {
+			a = ui.bot.Act(v)
+		}
+		err := g.Do(a, ev)
+		if err != nil {
+			v.err = err
+			v.failures++
+			continue
+		}
+		if g.Won() {
+			ui.outcome = Won
+			return true
+		}
+		return false
+	}
+}
+
+func (ui *botUI) Death(g *Game) {
+	ui.outcome = Died
+}
+
+func (ui *botUI) CriticalHPWarning(g *Game) {
+}
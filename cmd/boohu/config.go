@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// Config holds the options of the configuration file. The file has one
+// “Name = value” option per line, and lines starting with “#” are comments.
+// Colors of the palette are set with their variable names, as in
+// “ColorFgPlayer = 5”. Keys are bound to commands with “Key k = Command”
+// lines, or “ExamineKey k = Command” for the keys used when choosing a
+// position with the cursor.
+type Config struct {
+	ExploreDelay time.Duration // delay between two autoexplore steps
+	CriticalHP   int           // HP under which the player is warned, 0 for the attack's damage
+	ConfirmQuit  bool          // ask confirmation before quitting without saving
+	keys         *keymap
+	examineKeys  *keymap
+	bindings     []binding // keys bound in the file
+	examineBinds []binding
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ExploreDelay: 10 * time.Millisecond,
+		ConfirmQuit:  true,
+	}
+}
+
+// ConfigFile returns the path of the configuration file.
+func ConfigFile() string {
+	var xdg string
+	if os.Getenv("GOOS") == "windows" {
+		xdg = os.Getenv("APPDATA")
+	} else {
+		xdg = os.Getenv("XDG_CONFIG_HOME")
+	}
+	if xdg == "" {
+		xdg = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(xdg, "boohu", "config")
+}
+
+func paletteEntries() map[string]*termbox.Attribute {
+	return map[string]*termbox.Attribute{
+		"ColorBgLOS":              &ColorBgLOS,
+		"ColorBgDark":             &ColorBgDark,
+		"ColorBg":                 &ColorBg,
+		"ColorBgCloud":            &ColorBgCloud,
+		"ColorFgLOS":              &ColorFgLOS,
+		"ColorFgDark":             &ColorFgDark,
+		"ColorFg":                 &ColorFg,
+		"ColorFgPlayer":           &ColorFgPlayer,
+		"ColorFgMonster":          &ColorFgMonster,
+		"ColorFgSleepingMonster":  &ColorFgSleepingMonster,
+		"ColorFgWanderingMonster": &ColorFgWanderingMonster,
+		"ColorFgConfusedMonster":  &ColorFgConfusedMonster,
+		"ColorFgCollectable":      &ColorFgCollectable,
+		"ColorFgStairs":           &ColorFgStairs,
+		"ColorFgGold":             &ColorFgGold,
+		"ColorFgHPok":             &ColorFgHPok,
+		"ColorFgHPwounded":        &ColorFgHPwounded,
+		"ColorFgHPcritical":       &ColorFgHPcritical,
+		"ColorFgMPok":             &ColorFgMPok,
+		"ColorFgMPpartial":        &ColorFgMPpartial,
+		"ColorFgMPcritical":       &ColorFgMPcritical,
+		"ColorFgStatusGood":       &ColorFgStatusGood,
+		"ColorFgStatusBad":        &ColorFgStatusBad,
+		"ColorFgStatusOther":      &ColorFgStatusOther,
+		"ColorFgExcluded":         &ColorFgExcluded,
+		"ColorFgTargetMode":       &ColorFgTargetMode,
+	}
+}
+
+// ConfigError is an invalid line of the configuration file.
+type ConfigError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// ConfigErrors lists every invalid line of the configuration file.
+type ConfigErrors []*ConfigError
+
+func (errs ConfigErrors) Error() string {
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// LoadConfig reads the configuration file, if it exists, and changes the
+// palette accordingly. Invalid lines are reported as ConfigErrors.
+func LoadConfig(file string) (Config, error) {
+	cfg := DefaultConfig()
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return cfg, cfg.bindKeys(file)
+	}
+	if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+	palette := paletteEntries()
+	errs := ConfigErrors{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		msg := cfg.parseOption(line, n, palette)
+		if msg != "" {
+			errs = append(errs, &ConfigError{File: file, Line: n, Msg: msg})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.bindKeys(file); err != nil {
+		errs = append(errs, err.(ConfigErrors)...)
+	}
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// parseOption sets the option of line, and returns a description of the
+// problem if it cannot.
+func (cfg *Config) parseOption(line string, n int, palette map[string]*termbox.Attribute) string {
+	if fields := strings.Fields(line); fields[0] == "Key" || fields[0] == "ExamineKey" {
+		return cfg.parseBinding(fields, n)
+	}
+	i := strings.Index(line, "=")
+	if i < 0 {
+		return fmt.Sprintf("expected “Name = value”, found “%s”", line)
+	}
+	name := strings.TrimSpace(line[:i])
+	value := strings.TrimSpace(line[i+1:])
+	switch name {
+	case "ExploreDelay":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Sprintf("ExploreDelay: invalid duration “%s” (examples: 10ms, 0s)", value)
+		}
+		cfg.ExploreDelay = d
+	case "CriticalHPWarning":
+		hp, err := strconv.Atoi(value)
+		if err != nil || hp < 0 {
+			return fmt.Sprintf("CriticalHPWarning: invalid number of HP “%s”", value)
+		}
+		cfg.CriticalHP = hp
+	case "ConfirmQuit":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Sprintf("ConfirmQuit: expected true or false, found “%s”", value)
+		}
+		cfg.ConfirmQuit = b
+	default:
+		attr, ok := palette[name]
+		if !ok {
+			if opt := closestOption(name, palette); opt != "" {
+				return fmt.Sprintf("unknown option “%s” (did you mean “%s”?)", name, opt)
+			}
+			return fmt.Sprintf("unknown option “%s”", name)
+		}
+		c, err := strconv.Atoi(value)
+		if err != nil || c < 0 || c > 256 {
+			return fmt.Sprintf("%s: invalid color “%s” (expected a number from 0 to 256)", name, value)
+		}
+		*attr = termbox.Attribute(c)
+	}
+	return ""
+}
+
+// parseBinding adds the binding of a “Key k = Command” line.
+func (cfg *Config) parseBinding(fields []string, n int) string {
+	if len(fields) != 4 || fields[2] != "=" {
+		return fmt.Sprintf("expected “%s k = Command”, found “%s”", fields[0], strings.Join(fields, " "))
+	}
+	k, err := parseKey(fields[1])
+	if err != nil {
+		return err.Error()
+	}
+	cmd, ok := parseCommand(fields[3])
+	if !ok {
+		return fmt.Sprintf("unknown command “%s”", fields[3])
+	}
+	b := binding{Key: k, Cmd: cmd, Line: n}
+	if fields[0] == "ExamineKey" {
+		if !cmd.isExamineCommand() && cmd != cmdHelp {
+			return fmt.Sprintf("%s is not a command for choosing a position: use “Key %s = %s”", cmd, fields[1], cmd)
+		}
+		cfg.examineBinds = append(cfg.examineBinds, b)
+	} else {
+		if cmd.isExamineCommand() {
+			return fmt.Sprintf("%s is a command for choosing a position: use “ExamineKey %s = %s”", cmd, fields[1], cmd)
+		}
+		cfg.bindings = append(cfg.bindings, b)
+	}
+	return ""
+}
+
+// bindKeys makes the keymaps from the default bindings and those of the
+// configuration file. The cursor is moved with the movement keys of the
+// player's turn.
+func (cfg *Config) bindKeys(file string) error {
+	errs := ConfigErrors{}
+	keys, err := newKeymap(file, defaultKeys, cfg.bindings)
+	if err != nil {
+		errs = append(errs, err.(ConfigErrors)...)
+		keys, _ = newKeymap(file, defaultKeys, nil)
+	}
+	moves := []binding{}
+	for _, b := range keys.bindings {
+		if b.Cmd <= cmdMoveSE {
+			moves = append(moves, b)
+		}
+	}
+	examineKeys, err := newKeymap(file, append(moves, defaultExamineKeys...), cfg.examineBinds)
+	if err != nil {
+		errs = append(errs, err.(ConfigErrors)...)
+	}
+	cfg.keys = keys
+	cfg.examineKeys = examineKeys
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// closestOption returns the option whose name is the closest to name, if it
+// looks like a misspelling of it.
+func closestOption(name string, palette map[string]*termbox.Attribute) string {
+	opts := []string{"ExploreDelay", "CriticalHPWarning", "ConfirmQuit"}
+	for opt := range palette {
+		opts = append(opts, opt)
+	}
+	sort.Strings(opts)
+	best := ""
+	bestDist := 4
+	for _, opt := range opts {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(opt)); d < bestDist {
+			best = opt
+			bestDist = d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
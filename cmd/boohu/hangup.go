@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	termbox "github.com/nsf/termbox-go"
+)
+
+// CatchHangup makes the game save itself at the player's next turn when the
+// terminal is closed or the process is asked to terminate, instead of being
+// killed in the middle of it.
+func (ui *termui) CatchHangup() {
+	ui.hangup = make(chan struct{})
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		close(ui.hangup)
+		// wake up the game if it is waiting for a key; resting and
+		// autoexplore stop by themselves
+		termbox.Interrupt()
+	}()
+}
+
+// HungUp reports whether the game has to be saved and closed.
+func (ui *termui) HungUp() bool {
+	if ui.hangup == nil {
+		return false
+	}
+	select {
+	case <-ui.hangup:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"strings"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// baseColors are the RGB colors of the 16 base terminal colors, which depend
+// on the terminal's configuration. defaultFg and defaultBg are used for
+// ColorDefault.
+var (
+	baseColors = [16]string{
+		"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+		"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+	}
+	defaultFg = "#e5e5e5"
+	defaultBg = "#000000"
+)
+
+// solarizedColors are the base colors of a terminal configured with the
+// solarized palette, as expected by the -s option.
+var solarizedColors = [16]string{
+	"#073642", "#dc322f", "#859900", "#b58900", "#268bd2", "#d33682", "#2aa198", "#eee8d5",
+	"#002b36", "#cb4b16", "#586e75", "#657b83", "#839496", "#6c71c4", "#93a1a1", "#fdf6e3",
+}
+
+// htmlColor returns the RGB color of a terminal color attribute in 256-color
+// output mode, or def for the default color.
+func htmlColor(a termbox.Attribute, def string) string {
+	c := int(a & 0x1FF)
+	if c == 0 {
+		return def
+	}
+	c--
+	switch {
+	case c < 16:
+		return baseColors[c]
+	case c < 232:
+		levels := []int{0, 95, 135, 175, 215, 255}
+		c -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[c/36], levels[c/6%6], levels[c%6])
+	default:
+		gray := 8 + 10*(c-232)
+		return fmt.Sprintf("#%02x%02x%02x", gray, gray, gray)
+	}
+}
+
+// htmlCell is a map cell as drawn in the terminal.
+type htmlCell struct {
+	r      rune
+	fg, bg termbox.Attribute
+}
+
+// legendEntry explains a glyph of the map.
+type legendEntry struct {
+	cell htmlCell
+	desc string
+}
+
+// HTMLDump returns the character dump as a self-contained HTML page, with
+// the map colored as in the terminal, descriptions of map cells as tooltips,
+// and a legend.
+func (ui *termui) HTMLDump(g *boohu.Game) string {
+	dump := g.Dump()
+	textMap := g.DumpDungeon()
+	i := strings.Index(dump, textMap)
+	if i < 0 {
+		// should not happen: keep the text map
+		i = len(dump)
+		textMap = ""
+	}
+	// one CSS class per pair of colors
+	styles := map[htmlCell]string{}
+	colors := []htmlCell{}
+	style := func(c htmlCell) string {
+		c.r = 0
+		if class, ok := styles[c]; ok {
+			return class
+		}
+		class := fmt.Sprintf("c%d", len(styles))
+		styles[c] = class
+		colors = append(colors, c)
+		return class
+	}
+	legend := []legendEntry{}
+	described := map[string]bool{}
+	mapBuf := &bytes.Buffer{}
+	for y := 0; y < g.Dungeon.Heigth; y++ {
+		mapBuf.WriteString("│")
+		for x := 0; x < g.Dungeon.Width; x++ {
+			pos := boohu.Position{X: x, Y: y}
+			r, fg, bg, ok := PositionCell(g, pos)
+			if !ok {
+				r, fg, bg = ' ', ColorFg, ColorBg
+			}
+			c := htmlCell{r: r, fg: fg, bg: bg}
+			desc := CellDescription(g, pos, r)
+			if desc == "" {
+				fmt.Fprintf(mapBuf, `<span class="%s">%s</span>`, style(c), html.EscapeString(string(r)))
+				continue
+			}
+			fmt.Fprintf(mapBuf, `<span class="%s" title="%s">%s</span>`, style(c), html.EscapeString(desc), html.EscapeString(string(r)))
+			key := string(r) + desc
+			if !described[key] {
+				described[key] = true
+				legend = append(legend, legendEntry{cell: c, desc: desc})
+			}
+		}
+		mapBuf.WriteString("│\n")
+	}
+	legendBuf := &bytes.Buffer{}
+	legendBuf.WriteString("<h2>Legend</h2>\n<table>\n")
+	for _, e := range legend {
+		fmt.Fprintf(legendBuf, "<tr><td><span class=\"%s\">%s</span></td><td>%s</td></tr>\n",
+			style(e.cell), html.EscapeString(string(e.cell.r)), html.EscapeString(e.desc))
+	}
+	fmt.Fprintf(legendBuf, "<tr><td><span class=\"%s\">.</span></td><td>in view</td></tr>\n", style(htmlCell{fg: ColorFgLOS, bg: ColorBgLOS}))
+	fmt.Fprintf(legendBuf, "<tr><td><span class=\"%s\">.</span></td><td>explored, out of view</td></tr>\n", style(htmlCell{fg: ColorFgDark, bg: ColorBgDark}))
+	fmt.Fprintf(legendBuf, "<tr><td><span class=\"%s\">.</span></td><td>excluded from automatic travel</td></tr>\n", style(htmlCell{fg: ColorFgExcluded, bg: ColorBgDark}))
+	if !described["¤unexplored"] {
+		fmt.Fprintf(legendBuf, "<tr><td><span class=\"%s\">¤</span></td><td>unexplored</td></tr>\n", style(htmlCell{fg: ColorFgDark, bg: ColorBgDark}))
+	}
+	legendBuf.WriteString("</table>\n")
+	buf := &bytes.Buffer{}
+	title := "Boohu character dump"
+	if g.Name != "" {
+		title += ": " + g.Name
+	}
+	fmt.Fprintf(buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n", html.EscapeString(title))
+	fmt.Fprintf(buf, "body { background: %s; color: %s; }\n", htmlColor(ColorBg, defaultBg), htmlColor(ColorFg, defaultFg))
+	buf.WriteString("pre { font-family: \"DejaVu Sans Mono\", monospace; line-height: 1.15; }\n")
+	buf.WriteString("span[title] { cursor: help; }\n")
+	buf.WriteString("table { border-collapse: collapse; }\ntd { padding: 0 1em 0 0; font-family: monospace; }\n")
+	for _, c := range colors {
+		fg, bg := c.fg, c.bg
+		if fg&termbox.AttrReverse != 0 || bg&termbox.AttrReverse != 0 {
+			fg, bg = bg, fg
+		}
+		fmt.Fprintf(buf, ".%s { color: %s; background: %s; }\n", styles[c], htmlColor(fg, defaultFg), htmlColor(bg, defaultBg))
+	}
+	buf.WriteString("</style>\n</head>\n<body>\n<pre>\n")
+	buf.WriteString(html.EscapeString(dump[:i]))
+	buf.Write(mapBuf.Bytes())
+	buf.WriteString(html.EscapeString(dump[i+len(textMap):]))
+	buf.WriteString("\n</pre>\n")
+	buf.Write(legendBuf.Bytes())
+	buf.WriteString("</body>\n</html>\n")
+	return buf.String()
+}
+
+// CellDescription describes what is drawn with r at pos, or returns an empty
+// string for plain walls and floor.
+func CellDescription(g *boohu.Game, pos boohu.Position, r rune) string {
+	switch r {
+	case '@':
+		return "you"
+	case '¤':
+		return "unexplored"
+	case '§':
+		return "cloud"
+	case '#', '.', ' ':
+		return ""
+	}
+	if m := g.MonsterAt(pos); m.Exists() && r == m.Kind.Letter() {
+		desc := m.Kind.String()
+		switch {
+		case m.Status(boohu.MonsConfused):
+			desc += " (confused)"
+		case m.State == boohu.Resting:
+			desc += " (sleeping)"
+		case m.State == boohu.Wandering:
+			desc += " (wandering)"
+		}
+		return desc
+	}
+	if c, ok := g.Collectables[pos]; ok {
+		return c.Consumable.String()
+	}
+	if eq, ok := g.Equipables[pos]; ok {
+		return eq.String()
+	}
+	if rod, ok := g.Rods[pos]; ok {
+		return rod.String()
+	}
+	if g.Stairs[pos] {
+		return "stairs"
+	}
+	if _, ok := g.Gold[pos]; ok {
+		return "gold"
+	}
+	return ""
+}
+
+// WriteHTMLDump writes the HTML dump to file.
+func (ui *termui) WriteHTMLDump(g *boohu.Game, file string) error {
+	return ioutil.WriteFile(file, []byte(ui.HTMLDump(g)), 0644)
+}
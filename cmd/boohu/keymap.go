@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// command is what a key does, either during the player's turn or when
+// choosing a position with the cursor.
+type command int
+
+const (
+	cmdMoveW command = iota
+	cmdMoveS
+	cmdMoveN
+	cmdMoveE
+	cmdMoveNW
+	cmdMoveNE
+	cmdMoveSW
+	cmdMoveSE
+	cmdWait
+	cmdRest
+	cmdDescend
+	cmdQuaff
+	cmdEquip
+	cmdAutoexplore
+	cmdExamine
+	cmdThrow
+	cmdEvoke
+	cmdCharacter
+	cmdLog
+	cmdDump
+	cmdSave
+	cmdQuit
+	cmdMorgue
+	cmdStats
+	cmdWizard
+	cmdOverlay
+	cmdHelp
+	cmdNextMonster
+	cmdPreviousMonster
+	cmdNextStairs
+	cmdNextObject
+	cmdTarget
+	cmdDescribe
+	cmdExclude
+)
+
+// moveCommands are the movement commands in the order used by help screens.
+var moveCommands = []command{cmdMoveW, cmdMoveS, cmdMoveN, cmdMoveE, cmdMoveNW, cmdMoveNE, cmdMoveSW, cmdMoveSE}
+
+// commandNames are the names of the commands in the configuration file.
+var commandNames = map[command]string{
+	cmdMoveW:           "MoveWest",
+	cmdMoveS:           "MoveSouth",
+	cmdMoveN:           "MoveNorth",
+	cmdMoveE:           "MoveEast",
+	cmdMoveNW:          "MoveNorthWest",
+	cmdMoveNE:          "MoveNorthEast",
+	cmdMoveSW:          "MoveSouthWest",
+	cmdMoveSE:          "MoveSouthEast",
+	cmdWait:            "Wait",
+	cmdRest:            "Rest",
+	cmdDescend:         "Descend",
+	cmdQuaff:           "Quaff",
+	cmdEquip:           "Equip",
+	cmdAutoexplore:     "Autoexplore",
+	cmdExamine:         "Examine",
+	cmdThrow:           "Throw",
+	cmdEvoke:           "Evoke",
+	cmdCharacter:       "Character",
+	cmdLog:             "Messages",
+	cmdDump:            "Dump",
+	cmdSave:            "Save",
+	cmdQuit:            "Quit",
+	cmdMorgue:          "Morgue",
+	cmdStats:           "Stats",
+	cmdWizard:          "Wizard",
+	cmdOverlay:         "Overlay",
+	cmdHelp:            "Help",
+	cmdNextMonster:     "NextMonster",
+	cmdPreviousMonster: "PreviousMonster",
+	cmdNextStairs:      "NextStairs",
+	cmdNextObject:      "NextObject",
+	cmdTarget:          "Target",
+	cmdDescribe:        "Describe",
+	cmdExclude:         "Exclude",
+}
+
+func (c command) String() string {
+	return commandNames[c]
+}
+
+func parseCommand(name string) (command, bool) {
+	for cmd, cname := range commandNames {
+		if cname == name {
+			return cmd, true
+		}
+	}
+	return 0, false
+}
+
+// key is a key as reported by termbox: either a character, or a special key
+// when Ch is zero.
+type key struct {
+	Ch  rune
+	Key termbox.Key
+}
+
+func eventKey(tev termbox.Event) key {
+	if tev.Ch != 0 {
+		return key{Ch: tev.Ch}
+	}
+	return key{Key: tev.Key}
+}
+
+// specialKeys are the names of the keys that are not characters.
+var specialKeys = map[string]termbox.Key{
+	"Up":        termbox.KeyArrowUp,
+	"Down":      termbox.KeyArrowDown,
+	"Left":      termbox.KeyArrowLeft,
+	"Right":     termbox.KeyArrowRight,
+	"Enter":     termbox.KeyEnter,
+	"Space":     termbox.KeySpace,
+	"Tab":       termbox.KeyTab,
+	"Backspace": termbox.KeyBackspace2,
+	"Delete":    termbox.KeyDelete,
+	"Home":      termbox.KeyHome,
+	"End":       termbox.KeyEnd,
+	"PageUp":    termbox.KeyPgup,
+	"PageDown":  termbox.KeyPgdn,
+}
+
+func init() {
+	for c := 'A'; c <= 'Z'; c++ {
+		switch c {
+		case 'H', 'I', 'M':
+			// same as Backspace, Tab and Enter
+			continue
+		}
+		specialKeys["Ctrl-"+string(c)] = termbox.KeyCtrlA + termbox.Key(c-'A')
+	}
+}
+
+// parseKey returns the key of a name used in the configuration file: either a
+// single character or the name of a special key.
+func parseKey(name string) (key, error) {
+	if r := []rune(name); len(r) == 1 {
+		return key{Ch: r[0]}, nil
+	}
+	if k, ok := specialKeys[name]; ok {
+		return key{Key: k}, nil
+	}
+	return key{}, fmt.Errorf("unknown key “%s” (keys are single characters or one of Up, Down, Left, Right, Enter, Space, Tab, Backspace, Delete, Home, End, PageUp, PageDown, Ctrl-A to Ctrl-Z)", name)
+}
+
+func (k key) String() string {
+	if k.Ch != 0 {
+		switch k.Ch {
+		case '.', ',':
+			return "“" + string(k.Ch) + "”"
+		}
+		return string(k.Ch)
+	}
+	for name, sk := range specialKeys {
+		if sk == k.Key {
+			return name
+		}
+	}
+	return fmt.Sprintf("key %d", k.Key)
+}
+
+// binding binds a key to a command. Bindings from the configuration file
+// remember their line for error messages.
+type binding struct {
+	Key  key
+	Cmd  command
+	Line int
+}
+
+// defaultKeys are the bindings for the player's turn, in the order used by
+// the help screen.
+var defaultKeys = []binding{
+	{Key: key{Ch: 'h'}, Cmd: cmdMoveW},
+	{Key: key{Ch: 'j'}, Cmd: cmdMoveS},
+	{Key: key{Ch: 'k'}, Cmd: cmdMoveN},
+	{Key: key{Ch: 'l'}, Cmd: cmdMoveE},
+	{Key: key{Ch: 'y'}, Cmd: cmdMoveNW},
+	{Key: key{Ch: 'u'}, Cmd: cmdMoveNE},
+	{Key: key{Ch: 'b'}, Cmd: cmdMoveSW},
+	{Key: key{Ch: 'n'}, Cmd: cmdMoveSE},
+	{Key: key{Ch: '4'}, Cmd: cmdMoveW},
+	{Key: key{Ch: '2'}, Cmd: cmdMoveS},
+	{Key: key{Ch: '8'}, Cmd: cmdMoveN},
+	{Key: key{Ch: '6'}, Cmd: cmdMoveE},
+	{Key: key{Ch: '7'}, Cmd: cmdMoveNW},
+	{Key: key{Ch: '9'}, Cmd: cmdMoveNE},
+	{Key: key{Ch: '1'}, Cmd: cmdMoveSW},
+	{Key: key{Ch: '3'}, Cmd: cmdMoveSE},
+	{Key: key{Key: termbox.KeyArrowLeft}, Cmd: cmdMoveW},
+	{Key: key{Key: termbox.KeyArrowDown}, Cmd: cmdMoveS},
+	{Key: key{Key: termbox.KeyArrowUp}, Cmd: cmdMoveN},
+	{Key: key{Key: termbox.KeyArrowRight}, Cmd: cmdMoveE},
+	{Key: key{Ch: 'r'}, Cmd: cmdRest},
+	{Key: key{Ch: '.'}, Cmd: cmdWait},
+	{Key: key{Ch: '5'}, Cmd: cmdWait},
+	{Key: key{Ch: '>'}, Cmd: cmdDescend},
+	{Key: key{Ch: 'q'}, Cmd: cmdQuaff},
+	{Key: key{Ch: 'a'}, Cmd: cmdQuaff},
+	{Key: key{Ch: 'e'}, Cmd: cmdEquip},
+	{Key: key{Ch: 'g'}, Cmd: cmdEquip},
+	{Key: key{Ch: ','}, Cmd: cmdEquip},
+	{Key: key{Ch: 'o'}, Cmd: cmdAutoexplore},
+	{Key: key{Ch: 'x'}, Cmd: cmdExamine},
+	{Key: key{Ch: 't'}, Cmd: cmdThrow},
+	{Key: key{Ch: 'f'}, Cmd: cmdThrow},
+	{Key: key{Ch: 'v'}, Cmd: cmdEvoke},
+	{Key: key{Ch: 'z'}, Cmd: cmdEvoke},
+	{Key: key{Ch: '%'}, Cmd: cmdCharacter},
+	{Key: key{Ch: 'C'}, Cmd: cmdCharacter},
+	{Key: key{Ch: 'm'}, Cmd: cmdLog},
+	{Key: key{Key: termbox.KeyCtrlP}, Cmd: cmdLog},
+	{Key: key{Ch: '#'}, Cmd: cmdDump},
+	{Key: key{Ch: 'S'}, Cmd: cmdSave},
+	{Key: key{Key: termbox.KeyCtrlQ}, Cmd: cmdQuit},
+	{Key: key{Ch: 'M'}, Cmd: cmdMorgue},
+	{Key: key{Ch: 'H'}, Cmd: cmdStats},
+	{Key: key{Key: termbox.KeyCtrlW}, Cmd: cmdWizard},
+	{Key: key{Key: termbox.KeyCtrlO}, Cmd: cmdOverlay},
+	{Key: key{Ch: '?'}, Cmd: cmdHelp},
+}
+
+// defaultExamineKeys are the bindings for choosing a position with the
+// cursor, besides the movement keys of the player's turn, which move the
+// cursor.
+var defaultExamineKeys = []binding{
+	{Key: key{Ch: '+'}, Cmd: cmdNextMonster},
+	{Key: key{Ch: '-'}, Cmd: cmdPreviousMonster},
+	{Key: key{Ch: '>'}, Cmd: cmdNextStairs},
+	{Key: key{Ch: 'o'}, Cmd: cmdNextObject},
+	{Key: key{Ch: '.'}, Cmd: cmdTarget},
+	{Key: key{Key: termbox.KeyEnter}, Cmd: cmdTarget},
+	{Key: key{Ch: 'v'}, Cmd: cmdDescribe},
+	{Key: key{Ch: 'd'}, Cmd: cmdDescribe},
+	{Key: key{Ch: 'e'}, Cmd: cmdExclude},
+	{Key: key{Ch: '?'}, Cmd: cmdHelp},
+}
+
+// isExamineCommand reports whether cmd is used when choosing a position with
+// the cursor rather than during the player's turn. Movement and help commands
+// are used in both cases.
+func (c command) isExamineCommand() bool {
+	return c >= cmdNextMonster
+}
+
+// keymap binds keys to commands, in a given order.
+type keymap struct {
+	bindings []binding
+	commands map[key]command
+}
+
+// newKeymap returns a keymap with the bindings of the configuration file
+// added to the default ones. A command bound in the configuration file loses
+// its default keys. Keys bound to several commands are reported as errors.
+func newKeymap(file string, defaults, user []binding) (*keymap, error) {
+	rebound := map[command]bool{}
+	for _, b := range user {
+		rebound[b.Cmd] = true
+	}
+	km := &keymap{commands: map[key]command{}}
+	for _, b := range defaults {
+		if !rebound[b.Cmd] {
+			km.bindings = append(km.bindings, b)
+		}
+	}
+	km.bindings = append(km.bindings, user...)
+	errs := ConfigErrors{}
+	bound := map[key]binding{}
+	for _, b := range km.bindings {
+		if ob, ok := bound[b.Key]; ok && ob.Cmd != b.Cmd {
+			err := &ConfigError{File: file, Line: b.Line}
+			switch {
+			case ob.Line == 0:
+				err.Msg = fmt.Sprintf("key %s cannot be bound to %s: it is bound to %s by default", b.Key, b.Cmd, ob.Cmd)
+			case b.Line == 0:
+				err.Line = ob.Line
+				err.Msg = fmt.Sprintf("key %s cannot be bound to %s: it is bound to %s by default", b.Key, ob.Cmd, b.Cmd)
+			default:
+				err.Msg = fmt.Sprintf("key %s is bound to both %s and %s", b.Key, ob.Cmd, b.Cmd)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		bound[b.Key] = b
+		km.commands[b.Key] = b.Cmd
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return km, nil
+}
+
+// Command returns the command bound to the key of tev.
+func (km *keymap) Command(tev termbox.Event) (command, bool) {
+	cmd, ok := km.commands[eventKey(tev)]
+	return cmd, ok
+}
+
+// Keys returns the keys bound to cmd, in the keymap's order.
+func (km *keymap) Keys(cmd command) []key {
+	keys := []key{}
+	for _, b := range km.bindings {
+		if b.Cmd == cmd && km.commands[b.Key] == cmd {
+			keys = append(keys, b.Key)
+		}
+	}
+	return keys
+}
+
+// Describe returns a description of the keys bound to cmd for help screens,
+// such as “q or a”.
+func (km *keymap) Describe(cmd command) string {
+	names := []string{}
+	for _, k := range km.Keys(cmd) {
+		names = append(names, k.String())
+	}
+	return joinAlternatives(names)
+}
+
+// DescribeMoves returns a description of the movement keys for help screens,
+// such as “h/j/k/l/y/u/b/n or 4/2/8/6/7/9/1/3”.
+func (km *keymap) DescribeMoves() string {
+	keys := [][]key{}
+	for _, cmd := range moveCommands {
+		keys = append(keys, km.Keys(cmd))
+	}
+	alts := []string{}
+	for i := 0; ; i++ {
+		names := []string{}
+		for _, ks := range keys {
+			if i < len(ks) {
+				names = append(names, ks[i].String())
+			}
+		}
+		if len(names) == 0 {
+			break
+		}
+		alts = append(alts, strings.Join(names, "/"))
+	}
+	return joinAlternatives(alts)
+}
+
+// keyCommand returns the command bound in km to the key of tev, which was
+// just recorded for the replay. Replays are played with the default keys, so
+// the recorded key is replaced by the default key of the command, or dropped
+// if it is bound to no command.
+func (ui *termui) keyCommand(g *boohu.Game, km *keymap, tev termbox.Event) (command, bool) {
+	cmd, ok := km.Command(tev)
+	if ui.replayer != nil || len(g.Inputs) == 0 {
+		return cmd, ok
+	}
+	if !ok {
+		g.Inputs = g.Inputs[:len(g.Inputs)-1]
+		return cmd, false
+	}
+	for _, b := range append(defaultKeys, defaultExamineKeys...) {
+		if b.Cmd == cmd {
+			g.Inputs[len(g.Inputs)-1] = boohu.ReplayInput{Kind: boohu.KeyInput, Ch: b.Key.Ch, Key: uint16(b.Key.Key)}
+			break
+		}
+	}
+	return cmd, true
+}
+
+func joinAlternatives(alts []string) string {
+	switch len(alts) {
+	case 0:
+		return "(no key)"
+	case 1:
+		return alts[0]
+	default:
+		return strings.Join(alts[:len(alts)-1], ", ") + " or " + alts[len(alts)-1]
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+func TestReplayCustomKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	err := ioutil.WriteFile(file, []byte("Key i = MoveWest\nKey w = Wait\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := &termui{config: cfg}
+	g := boohu.NewGame(nil)
+	// h and . lost their default bindings
+	played := []command{}
+	for _, ch := range "ihw.i" {
+		tev := termbox.Event{Type: termbox.EventKey, Ch: ch}
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.KeyInput, Ch: ch})
+		if cmd, ok := ui.keyCommand(g, cfg.keys, tev); ok {
+			played = append(played, cmd)
+		}
+	}
+	defaults, _ := LoadConfig(filepath.Join(t.TempDir(), "none"))
+	replayed := []command{}
+	for _, in := range g.Inputs {
+		tev := termbox.Event{Type: termbox.EventKey, Ch: in.Ch, Key: termbox.Key(in.Key)}
+		cmd, ok := defaults.keys.Command(tev)
+		if !ok {
+			t.Errorf("Recorded key %q is not bound by default", in.Ch)
+			continue
+		}
+		replayed = append(replayed, cmd)
+	}
+	want := []command{cmdMoveW, cmdWait, cmdMoveW}
+	if len(played) != len(want) || len(replayed) != len(want) {
+		t.Fatalf("Played %v and replayed %v instead of %v", played, replayed, want)
+	}
+	for i := range want {
+		if played[i] != want[i] || replayed[i] != want[i] {
+			t.Errorf("Played %v and replayed %v instead of %v", played, replayed, want)
+			break
+		}
+	}
+}
+
+func TestReplayQuitWithoutConfirmation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ConfirmQuit = false
+	ui := &termui{config: cfg}
+	g := boohu.NewGame(nil)
+	if !ui.Quit(g) {
+		t.Fatal("Quit asked for confirmation")
+	}
+	// replays ask for it, as by default
+	if len(g.Inputs) != 1 || g.Inputs[0].Ch != 'Y' {
+		t.Errorf("Confirmation not recorded: %+v", g.Inputs)
+	}
+}
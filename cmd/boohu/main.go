@@ -6,16 +6,21 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"path/filepath"
+	"os"
 	"runtime"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/anaseto/boohu"
 	termbox "github.com/nsf/termbox-go"
 )
 
 type termui struct {
+	replayer *replayer
+	hangup   chan struct{} // closed when the game has to be saved and closed
+	config   Config
+	overlay  boohu.Overlay // wizard view of the game's maps
 }
 
 // colors: http://ethanschoonover.com/solarized
@@ -74,6 +79,9 @@ func SolarizedPalette() {
 	ColorFgStatusBad = 2
 	ColorFgStatusOther = 4
 	ColorFgTargetMode = 7
+	baseColors = solarizedColors
+	defaultFg = baseColors[12]
+	defaultBg = baseColors[8]
 }
 
 func WindowsPalette() {
@@ -106,14 +114,39 @@ func WindowsPalette() {
 
 func main() {
 	opt := flag.Bool("s", false, "Use true 16-color solarized palette")
+	seed := flag.Int64("seed", 0, "Use a fixed random seed for a new game (0 for a random one)")
+	replayFile := flag.String("replay", "", "Replay a game recorded in `file`")
+	simulate := flag.Int("simulate", 0, "Play `N` games with a bot and print statistics")
+	stats := flag.Bool("stats", false, "Print statistics of past games")
+	daily := flag.Bool("daily", false, "Play the daily challenge, the same dungeon for everyone on a given UTC day")
 	flag.Parse()
+	if *daily && *seed != 0 {
+		fmt.Fprintf(os.Stderr, "The daily challenge has its own seed: -seed cannot be used with -daily.\n")
+		os.Exit(2)
+	}
+	if *stats {
+		PrintStats()
+		return
+	}
+	if *simulate > 0 {
+		if *seed == 0 {
+			*seed = boohu.RandomSeed()
+		}
+		Simulate(*simulate, *seed)
+		return
+	}
 	if *opt {
 		SolarizedPalette()
 	} else if runtime.GOOS == "windows" {
 		WindowsPalette()
 	}
+	cfg, err := LoadConfig(ConfigFile())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in configuration file:\n%v\n", err)
+		os.Exit(1)
+	}
 
-	err := termbox.Init()
+	err = termbox.Init()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -124,17 +157,71 @@ func main() {
 		log.Println(err)
 	}
 
-	tui := &termui{}
+	tui := &termui{config: cfg}
+	if *replayFile != "" {
+		rep, err := boohu.LoadReplay(*replayFile)
+		if err != nil {
+			termbox.Close()
+			log.Fatalf("Error loading replay: %v", err)
+		}
+		tui.Replay(rep)
+	}
 	tui.DrawWelcome()
-	g := &game{}
+	g := boohu.NewGame(tui)
+	if !tui.ChooseCharacter(g) {
+		return
+	}
 	load, err := g.Load()
-	if !load {
-		g.InitLevel()
-	} else if err != nil {
+	if verr, ok := err.(*boohu.SaveVersionError); ok {
+		// do not start a new game, as saving it would overwrite the old one
+		termbox.Close()
+		fmt.Fprintf(os.Stderr, "Cannot load saved game: %v.\n", verr)
+		fmt.Fprintf(os.Stderr, "Use that version of boohu to continue the game, or remove the file to start a new one.\n")
+		os.Exit(1)
+	}
+	if cerr, ok := err.(*boohu.SaveCorruptedError); ok && cerr.Backup {
+		if tui.Confirm(fmt.Sprintf("Your saved game could not be loaded: %v. Do you want to load the backup of your previous save instead? (capital 'Y' to confirm)", cerr)) {
+			err = g.LoadBackup()
+		}
+	}
+	if !load && g.CanRecover() {
+		if tui.Confirm("Your last game did not end properly, probably because of a crash, and was not saved since it was loaded. Do you want to recover it from its last save? The recovery will be noted in your character dump. (capital 'Y' to recover it, any other key abandons it)") {
+			err = g.Recover()
+			load = true
+		} else {
+			g.RemoveSaveFile()
+		}
+	}
+	if *daily && load && err == nil && g.Daily == "" {
+		termbox.Close()
+		fmt.Fprintf(os.Stderr, "This character has a game in progress: continue it without -daily, or choose another character.\n")
+		os.Exit(1)
+	}
+	if !load || err != nil {
+		if *daily {
+			err := g.StartDaily(time.Now())
+			if err != nil {
+				termbox.Close()
+				fmt.Fprintf(os.Stderr, "Cannot start the daily challenge: %v.\n", err)
+				os.Exit(1)
+			}
+		} else {
+			if *seed == 0 {
+				*seed = boohu.RandomSeed()
+			}
+			g.SetSeed(*seed)
+		}
 		g.InitLevel()
-		g.Print("Error loading saved game… starting new game.")
+		if load {
+			g.Print("Error loading saved game… starting new game.")
+		}
 	}
-	g.ui = tui
+	g.SetCriticalHP(cfg.CriticalHP)
+	if g.AutoSaved {
+		g.AutoSaved = false
+		g.Print("Your game was automatically saved when the terminal was closed.")
+	}
+	tui.CatchHangup()
 	g.EventLoop()
 }
 
@@ -226,127 +313,163 @@ func (ui *termui) DrawLight(text string, x, y int, fg termbox.Attribute) {
 	}
 }
 
-func (ui *termui) HandlePlayerTurn(g *game, ev event) bool {
+func (ui *termui) HandlePlayerTurn(g *boohu.Game, ev boohu.Event) bool {
 getKey:
 	for {
+		if ui.HungUp() {
+			g.AutoSaved = true
+			g.SaveTurn(ev)
+			g.WriteReplay()
+			return true
+		}
 		ui.DrawDungeonView(g, false)
 		var err error
-		switch tev := termbox.PollEvent(); tev.Type {
+		switch tev := ui.PollEvent(g); tev.Type {
 		case termbox.EventKey:
-			if tev.Ch == 0 {
-				switch tev.Key {
-				case termbox.KeyArrowUp:
-					tev.Ch = 'k'
-				case termbox.KeyArrowRight:
-					tev.Ch = 'l'
-				case termbox.KeyArrowDown:
-					tev.Ch = 'j'
-				case termbox.KeyArrowLeft:
-					tev.Ch = 'h'
-				case termbox.KeyCtrlW:
-					if ui.Wizard(g) {
-						g.Wizard = true
-						g.Print("You are now in wizard mode and cannot obtain winner status.")
-						ui.DrawDungeonView(g, false)
-						continue getKey
-					}
-					g.Print("Ok, then.")
-					continue getKey
-				case termbox.KeyCtrlQ:
-					if ui.Quit(g) {
-						g.RemoveSaveFile()
-						return true
-					}
-					g.Print("Ok, then.")
-					continue getKey
-				case termbox.KeyCtrlP:
-					tev.Ch = 'm'
-				}
+			if ui.HungUp() {
+				continue getKey
 			}
-			switch tev.Ch {
-			case 'h', '4':
-				err = g.MovePlayer(g.Player.Pos.W(), ev)
-			case 'l', '6':
-				err = g.MovePlayer(g.Player.Pos.E(), ev)
-			case 'j', '2':
-				err = g.MovePlayer(g.Player.Pos.S(), ev)
-			case 'k', '8':
-				err = g.MovePlayer(g.Player.Pos.N(), ev)
-			case 'y', '7':
-				err = g.MovePlayer(g.Player.Pos.NW(), ev)
-			case 'b', '1':
-				err = g.MovePlayer(g.Player.Pos.SW(), ev)
-			case 'u', '9':
-				err = g.MovePlayer(g.Player.Pos.NE(), ev)
-			case 'n', '3':
-				err = g.MovePlayer(g.Player.Pos.SE(), ev)
-			case '.', '5':
-				g.WaitTurn(ev)
-			case 'r':
-				err = g.Rest(ev)
-			case '>':
-				if g.Stairs[g.Player.Pos] {
-					if g.Descend(ev) {
-						ui.Win(g)
-						return true
-					}
-					ui.DrawDungeonView(g, false)
-				} else {
-					err = errors.New("No stairs here.")
-				}
-			case 'e', 'g', ',':
-				err = ui.Equip(g, ev)
-			case 'q', 'a':
-				err = ui.SelectPotion(g, ev)
-			case 't', 'f':
-				err = ui.SelectProjectile(g, ev)
-			case 'v', 'z':
-				err = ui.SelectRod(g, ev)
-			case 'o':
-				err = g.Autoexplore(ev)
-			case 'x':
+			cmd, ok := ui.keyCommand(g, ui.config.keys, tev)
+			if !ok {
+				g.Print("Unknown key.")
+				continue getKey
+			}
+			var action boohu.Action
+			switch cmd {
+			case cmdMoveW:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.W}
+			case cmdMoveE:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.E}
+			case cmdMoveS:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.S}
+			case cmdMoveN:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.N}
+			case cmdMoveNW:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.NW}
+			case cmdMoveSW:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.SW}
+			case cmdMoveNE:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.NE}
+			case cmdMoveSE:
+				action = boohu.Action{Kind: boohu.MoveAction, Dir: boohu.SE}
+			case cmdWait:
+				action = boohu.Action{Kind: boohu.WaitAction}
+			case cmdRest:
+				action = boohu.Action{Kind: boohu.RestAction}
+			case cmdDescend:
+				action = boohu.Action{Kind: boohu.DescendAction}
+			case cmdEquip:
+				action = boohu.Action{Kind: boohu.EquipAction}
+			case cmdQuaff:
+				action, err = ui.SelectPotion(g, ev)
+			case cmdThrow:
+				action, err = ui.SelectProjectile(g, ev)
+			case cmdEvoke:
+				action, err = ui.SelectRod(g, ev)
+			case cmdAutoexplore:
+				action = boohu.Action{Kind: boohu.AutoexploreAction}
+			case cmdExamine:
 				b := ui.Examine(g)
 				ui.DrawDungeonView(g, false)
 				if !b {
 					continue getKey
-				} else if !g.MoveToTarget(ev) {
-					continue getKey
 				}
-			case '?':
+				action = boohu.Action{Kind: boohu.TravelAction, Target: *g.AutoTarget}
+			case cmdHelp:
 				ui.KeysHelp(g)
 				continue getKey
-			case '%', 'C':
+			case cmdCharacter:
 				ui.CharacterInfo(g)
 				continue getKey
-			case 'm':
+			case cmdLog:
 				ui.DrawPreviousLogs(g)
 				continue getKey
-			case 'S':
-				ev.Renew(g, 0)
-				g.Save()
+			case cmdMorgue:
+				if ui.replayer == nil {
+					ui.MorgueScreen(g)
+				}
+				continue getKey
+			case cmdStats:
+				if ui.replayer == nil {
+					ui.StatsScreen(g)
+				}
+				continue getKey
+			case cmdSave:
+				g.SaveTurn(ev)
+				if ui.replayer != nil {
+					// the replay goes on after the game was saved
+					return false
+				}
+				g.WriteReplay()
 				return true
-			case '#':
+			case cmdDump:
 				err := g.WriteDump()
+				if err == nil {
+					dumpFile, _ := g.DumpFile()
+					err = ui.WriteHTMLDump(g, dumpFile+".html")
+				}
 				if err != nil {
 					g.Print("Error writting dump to file.")
 				} else {
-					dataDir, _ := g.DataDir()
-					g.Printf("Dump written to %s.", filepath.Join(dataDir, "dump"))
+					dumpFile, _ := g.DumpFile()
+					g.Printf("Dump written to %s.", dumpFile)
+				}
+				continue getKey
+			case cmdQuit:
+				if ui.Quit(g) {
+					g.Abandoned = true
+					ui.WriteMorgue(g)
+					g.WriteStats()
+					g.RemoveSaveFile()
+					g.WriteReplay()
+					return true
+				}
+				g.Print("Ok, then.")
+				continue getKey
+			case cmdOverlay:
+				if !g.Wizard {
+					g.Print("Overlays are only available in wizard mode.")
+					continue getKey
+				}
+				ui.overlay = ui.overlay.Next()
+				g.Printf("Overlay: %s.", ui.overlay)
+				ui.DrawDungeonView(g, false)
+				continue getKey
+			case cmdWizard:
+				if g.Wizard {
+					if ui.WizardConsole(g, ev) {
+						// the restored turn is next in the event queue
+						return false
+					}
+					continue getKey
+				}
+				if ui.Wizard(g) {
+					g.Wizard = true
+					g.Print("You are now in wizard mode and cannot obtain winner status.")
+					g.Printf("Press %s again for the wizard console.", ui.config.keys.Describe(cmdWizard))
+					ui.DrawDungeonView(g, false)
+					continue getKey
 				}
+				g.Print("Ok, then.")
 				continue getKey
-			default:
-				err = errors.New("Unknown key.")
+			}
+			if err == nil {
+				err = g.Do(action, ev)
 			}
 			if err != nil {
 				g.Print(err.Error())
 				continue getKey
 			}
+			if g.Won() {
+				ui.Win(g)
+				return true
+			}
 			return false
 		}
 	}
 }
 
-func (ui *termui) DrawKeysDescription(g *game, actions []string) {
+func (ui *termui) DrawKeysDescription(g *boohu.Game, actions []string) {
 	termbox.Clear(ColorFg, ColorBg)
 	help := &bytes.Buffer{}
 	help.WriteString("┌────────────── Keys ────────────────────────────────────────────────────────\n")
@@ -361,51 +484,53 @@ func (ui *termui) DrawKeysDescription(g *game, actions []string) {
 	ui.WaitForContinue(g)
 }
 
-func (ui *termui) KeysHelp(g *game) {
+func (ui *termui) KeysHelp(g *boohu.Game) {
+	km := ui.config.keys
 	ui.DrawKeysDescription(g, []string{
-		"Movement", "h/j/k/l/y/u/b/n or numpad",
-		"Rest", "r",
-		"Wait", "“.” or 5",
-		"Use stairs", ">",
-		"Quaff potion", "q or a",
-		"Equip weapon/armour/...", "e or g",
-		"Autoexplore", "o",
-		"Examine", "x (? for help)",
-		"Throw item", "t or f (? for help)",
-		"Evoke rod", "v or z (? for help)",
-		"View Character Information", `% or C`,
-		"View previous messages", "m",
-		"Write character dump to file", "#",
-		"Save and Quit", "S",
-		"Quit without saving", "Ctrl-Q",
+		"Movement", km.DescribeMoves(),
+		"Rest", km.Describe(cmdRest),
+		"Wait", km.Describe(cmdWait),
+		"Use stairs", km.Describe(cmdDescend),
+		"Quaff potion", km.Describe(cmdQuaff),
+		"Equip weapon/armour/...", km.Describe(cmdEquip),
+		"Autoexplore", km.Describe(cmdAutoexplore),
+		"Examine", km.Describe(cmdExamine) + " (" + ui.config.examineKeys.Describe(cmdHelp) + " for help)",
+		"Throw item", km.Describe(cmdThrow) + " (" + ui.config.examineKeys.Describe(cmdHelp) + " for help)",
+		"Evoke rod", km.Describe(cmdEvoke) + " (" + ui.config.examineKeys.Describe(cmdHelp) + " for help)",
+		"View Character Information", km.Describe(cmdCharacter),
+		"View previous messages", km.Describe(cmdLog),
+		"View dumps of past games", km.Describe(cmdMorgue),
+		"View statistics of past games", km.Describe(cmdStats),
+		"Write character dump to file", km.Describe(cmdDump),
+		"Save and Quit", km.Describe(cmdSave),
+		"Quit without saving", km.Describe(cmdQuit),
+		"Wizard mode (console once in it)", km.Describe(cmdWizard),
+		"Cycle wizard overlays", km.Describe(cmdOverlay),
 	})
 }
 
-func (ui *termui) ExamineHelp(g *game) {
+func (ui *termui) ExamineHelp(g *boohu.Game) {
+	km := ui.config.examineKeys
 	ui.DrawKeysDescription(g, []string{
-		"Move cursor", "h/j/k/l/y/u/b/n or numpad",
-		"Cycle through monsters", "+",
-		"Cycle through stairs", ">",
-		"Cycle through objects", "o",
-		"Go to/select target", "“.” or enter",
-		"View target description", "v or d",
-		"Toggle exclude area from automatic travelling", "e",
+		"Move cursor", km.DescribeMoves(),
+		"Cycle through monsters", km.Describe(cmdNextMonster) + " (" + km.Describe(cmdPreviousMonster) + " backwards)",
+		"Cycle through stairs", km.Describe(cmdNextStairs),
+		"Cycle through objects", km.Describe(cmdNextObject),
+		"Go to/select target", km.Describe(cmdTarget),
+		"View target description", km.Describe(cmdDescribe),
+		"Toggle exclude area from automatic travelling", km.Describe(cmdExclude),
 	})
 }
 
-func (ui *termui) Equip(g *game, ev event) error {
-	return g.Equip(ev)
-}
-
-func (ui *termui) CharacterInfo(g *game) {
+func (ui *termui) CharacterInfo(g *boohu.Game) {
 	termbox.Clear(ColorFg, ColorBg)
 	b := bytes.Buffer{}
 	b.WriteString(formatText(
-		fmt.Sprintf("You are wielding %s. %s", Indefinite(g.Player.Weapon.String(), false), g.Player.Weapon.Desc()), 79))
+		fmt.Sprintf("You are wielding %s. %s", boohu.Indefinite(g.Player.Weapon.String(), false), g.Player.Weapon.Desc()), 79))
 	b.WriteString("\n\n")
 	b.WriteString(formatText(fmt.Sprintf("You are wearing a %s. %s", g.Player.Armour, g.Player.Armour.Desc()), 79))
 	b.WriteString("\n\n")
-	if g.Player.Shield != NoShield {
+	if g.Player.Shield != boohu.NoShield {
 		b.WriteString(formatText(fmt.Sprintf("You are wearing a %s. %s", g.Player.Shield, g.Player.Shield.Desc()), 79))
 		b.WriteString("\n\n")
 	}
@@ -416,7 +541,7 @@ func (ui *termui) CharacterInfo(g *game) {
 	ui.DrawDungeonView(g, false)
 }
 
-func (ui *termui) AptitudesText(g *game) string {
+func (ui *termui) AptitudesText(g *boohu.Game) string {
 	apts := []string{}
 	for apt, b := range g.Player.Aptitudes {
 		if b {
@@ -434,8 +559,8 @@ func (ui *termui) AptitudesText(g *game) string {
 	return text
 }
 
-func (ui *termui) DescribePosition(g *game, pos position, targ Targetter) {
-	mons, _ := g.MonsterAt(pos)
+func (ui *termui) DescribePosition(g *boohu.Game, pos boohu.Position, targ boohu.Targetter) {
+	mons := g.MonsterAt(pos)
 	c, okCollectable := g.Collectables[pos]
 	eq, okEq := g.Equipables[pos]
 	rod, okRod := g.Rods[pos]
@@ -449,22 +574,22 @@ func (ui *termui) DescribePosition(g *game, pos position, targ Targetter) {
 	case !targ.Reachable(g, pos):
 		desc = "This is out of reach."
 	case mons.Exists() && g.Player.LOS[pos]:
-		desc += fmt.Sprintf("You see %s (%s).", Indefinite(mons.Kind.String(), false), ui.MonsterInfo(mons))
+		desc += fmt.Sprintf("You see %s (%s).", boohu.Indefinite(mons.Kind.String(), false), ui.MonsterInfo(mons))
 	case g.Gold[pos] > 0:
 		desc += fmt.Sprintf("You see some gold (%d).", g.Gold[pos])
 	case okCollectable && c != nil:
 		if c.Quantity > 1 {
 			desc += fmt.Sprintf("You see %d %s there.", c.Quantity, c.Consumable)
 		} else {
-			desc += fmt.Sprintf("You see %s there.", Indefinite(c.Consumable.String(), false))
+			desc += fmt.Sprintf("You see %s there.", boohu.Indefinite(c.Consumable.String(), false))
 		}
 	case okEq:
-		desc += fmt.Sprintf("You see %s.", Indefinite(eq.String(), false))
+		desc += fmt.Sprintf("You see %s.", boohu.Indefinite(eq.String(), false))
 	case okRod:
 		desc += fmt.Sprintf("You see a %v.", rod)
 	case g.Stairs[pos]:
 		desc += "You see stairs downwards."
-	case g.Dungeon.Cell(pos).T == WallCell:
+	case g.Dungeon.Cell(pos).T == boohu.WallCell:
 		desc += "You see a wall."
 	default:
 		desc += "You see the ground."
@@ -472,17 +597,17 @@ func (ui *termui) DescribePosition(g *game, pos position, targ Targetter) {
 	g.Print(desc)
 }
 
-func (ui *termui) Examine(g *game) bool {
-	ex := &examiner{}
+func (ui *termui) Examine(g *boohu.Game) bool {
+	ex := &boohu.Examiner{}
 	err := ui.CursorAction(g, ex)
 	if err != nil {
 		g.Print(err.Error())
 		return false
 	}
-	return ex.done
+	return ex.Done()
 }
 
-func (ui *termui) ChooseTarget(g *game, targ Targetter) bool {
+func (ui *termui) ChooseTarget(g *boohu.Game, targ boohu.Targetter) bool {
 	err := ui.CursorAction(g, targ)
 	if err != nil {
 		g.Print(err.Error())
@@ -491,7 +616,7 @@ func (ui *termui) ChooseTarget(g *game, targ Targetter) bool {
 	return targ.Done()
 }
 
-func (ui *termui) CursorAction(g *game, targ Targetter) error {
+func (ui *termui) CursorAction(g *boohu.Game, targ boohu.Targetter) error {
 	pos := g.Player.Pos
 	minDist := 999
 	for _, mons := range g.Monsters {
@@ -504,11 +629,11 @@ func (ui *termui) CursorAction(g *game, targ Targetter) error {
 		}
 	}
 	var err error
-	var nstatic position
+	var nstatic boohu.Position
 	nmonster := 0
-	objects := []position{}
+	objects := []boohu.Position{}
 	nobject := 0
-	opos := position{-1, -1}
+	opos := boohu.Position{X: -1, Y: -1}
 loop:
 	for {
 		err = nil
@@ -519,43 +644,35 @@ loop:
 		targ.ComputeHighlight(g, pos)
 		termbox.SetCursor(pos.X, pos.Y)
 		ui.DrawDungeonView(g, true)
-		switch tev := termbox.PollEvent(); tev.Type {
+		switch tev := ui.PollEvent(g); tev.Type {
 		case termbox.EventKey:
 			npos := pos
-			if tev.Ch == 0 {
-				switch tev.Key {
-				case termbox.KeyArrowUp:
-					tev.Ch = 'k'
-				case termbox.KeyArrowRight:
-					tev.Ch = 'l'
-				case termbox.KeyArrowDown:
-					tev.Ch = 'j'
-				case termbox.KeyArrowLeft:
-					tev.Ch = 'h'
-				case termbox.KeyEsc:
-					break loop
-				case termbox.KeyEnter:
-					tev.Ch = '.'
-				}
+			if tev.Ch == 0 && tev.Key == termbox.KeyEsc {
+				break loop
 			}
-			switch tev.Ch {
-			case 'h', '4':
+			cmd, ok := ui.keyCommand(g, ui.config.examineKeys, tev)
+			if !ok {
+				g.Print("Invalid key.")
+				continue loop
+			}
+			switch cmd {
+			case cmdMoveW:
 				npos = pos.W()
-			case 'l', '6':
+			case cmdMoveE:
 				npos = pos.E()
-			case 'j', '2':
+			case cmdMoveS:
 				npos = pos.S()
-			case 'k', '8':
+			case cmdMoveN:
 				npos = pos.N()
-			case 'y', '7':
+			case cmdMoveNW:
 				npos = pos.NW()
-			case 'b', '1':
+			case cmdMoveSW:
 				npos = pos.SW()
-			case 'u', '9':
+			case cmdMoveNE:
 				npos = pos.NE()
-			case 'n', '3':
+			case cmdMoveSE:
 				npos = pos.SE()
-			case '>':
+			case cmdNextStairs:
 			search:
 				for i := 0; i < g.Dungeon.Width*g.Dungeon.Heigth; i++ {
 					for nstatic.X < g.Dungeon.Width-1 {
@@ -571,9 +688,9 @@ loop:
 						nstatic.Y = 0
 					}
 				}
-			case '+', '-':
+			case cmdNextMonster, cmdPreviousMonster:
 				for i := 0; i < len(g.Monsters); i++ {
-					if tev.Ch == '+' {
+					if cmd == cmdNextMonster {
 						nmonster++
 					} else {
 						nmonster--
@@ -589,7 +706,7 @@ loop:
 						break
 					}
 				}
-			case 'o':
+			case cmdNextObject:
 				if len(objects) == 0 {
 					for p := range g.Collectables {
 						objects = append(objects, p)
@@ -615,30 +732,28 @@ loop:
 						break
 					}
 				}
-			case 'v', 'd':
+			case cmdDescribe:
 				termbox.HideCursor()
 				ui.ViewPositionDescription(g, pos)
 				termbox.SetCursor(pos.X, pos.Y)
-			case '?':
+			case cmdHelp:
 				termbox.HideCursor()
 				ui.ExamineHelp(g)
 				termbox.SetCursor(pos.X, pos.Y)
-			case '.':
+			case cmdTarget:
 				err = targ.Action(g, pos)
 				if err != nil {
 					g.Print(err.Error())
 				} else {
 					break loop
 				}
-			case 'e':
+			case cmdExclude:
 				if !g.Dungeon.Cell(pos).Explored {
 					g.Print("You cannot choose an unexplored cell for exclusion.")
 				} else {
 					toggle := !g.ExclusionsMap[pos]
 					g.ComputeExclusion(pos, toggle)
 				}
-			default:
-				g.Print("Invalid key.")
 			}
 			if g.Dungeon.Valid(npos) {
 				pos = npos
@@ -650,8 +765,8 @@ loop:
 	return err
 }
 
-func (ui *termui) ViewPositionDescription(g *game, pos position) {
-	mons, _ := g.MonsterAt(pos)
+func (ui *termui) ViewPositionDescription(g *boohu.Game, pos boohu.Position) {
+	mons := g.MonsterAt(pos)
 	if mons.Exists() {
 		termbox.HideCursor()
 		ui.DrawMonsterDescription(g, mons)
@@ -670,7 +785,7 @@ func (ui *termui) ViewPositionDescription(g *game, pos position) {
 
 }
 
-func (ui *termui) MonsterInfo(m *monster) string {
+func (ui *termui) MonsterInfo(m *boohu.Monster) string {
 	infos := []string{}
 	infos = append(infos, m.State.String())
 	for st, i := range m.Statuses {
@@ -684,7 +799,7 @@ func (ui *termui) MonsterInfo(m *monster) string {
 	return strings.Join(infos, ", ")
 }
 
-func (ui *termui) DrawDungeonView(g *game, targetting bool) {
+func (ui *termui) DrawDungeonView(g *boohu.Game, targetting bool) {
 	err := termbox.Clear(ColorFg, ColorBg)
 	if err != nil {
 		log.Println(err)
@@ -701,9 +816,12 @@ func (ui *termui) DrawDungeonView(g *game, targetting bool) {
 		pos := m.CellPosition(i)
 		ui.DrawPosition(g, pos)
 	}
+	if g.Wizard && ui.overlay != boohu.NoOverlay {
+		ui.DrawOverlay(g)
+	}
 	ui.DrawText(fmt.Sprintf("[ %v (%d)", g.Player.Armour, g.Player.Armor()), 81, 0)
 	ui.DrawText(fmt.Sprintf(") %v (%d)", g.Player.Weapon, g.Player.Attack()), 81, 1)
-	if g.Player.Shield != NoShield {
+	if g.Player.Shield != boohu.NoShield {
 		if g.Player.Weapon.TwoHanded() {
 			ui.DrawText(fmt.Sprintf("] %v (unusable)", g.Player.Shield), 81, 2)
 		} else {
@@ -713,28 +831,53 @@ func (ui *termui) DrawDungeonView(g *game, targetting bool) {
 	if targetting {
 		ui.DrawColoredText("Targetting", 81, 20, ColorFgTargetMode)
 	}
+	if g.Wizard && ui.overlay != boohu.NoOverlay {
+		ui.DrawColoredText(fmt.Sprintf("Overlay: %s", ui.overlay), 81, 18, ColorFgTargetMode)
+	}
+	if ui.replayer != nil {
+		if ui.replayer.paused {
+			ui.DrawColoredText("Replay (paused)", 81, 19, ColorFgTargetMode)
+		} else {
+			ui.DrawColoredText("Replay", 81, 19, ColorFgTargetMode)
+		}
+	}
 	ui.DrawStatusLine(g)
 	ui.DrawLog(g)
 	termbox.Flush()
 }
 
-func (ui *termui) DrawPosition(g *game, pos position) {
+// DrawOverlay draws the current wizard overlay over the map.
+func (ui *termui) DrawOverlay(g *boohu.Game) {
+	for pos, r := range g.OverlayMap(ui.overlay) {
+		termbox.SetCell(pos.X, pos.Y, r, ColorFgTargetMode, ColorBgDark)
+	}
+}
+
+func (ui *termui) DrawPosition(g *boohu.Game, pos boohu.Position) {
+	r, fgColor, bgColor, ok := PositionCell(g, pos)
+	if ok {
+		termbox.SetCell(pos.X, pos.Y, r, fgColor, bgColor)
+	}
+}
+
+// PositionCell returns how the map cell at pos is drawn, or false if it is
+// not drawn.
+func PositionCell(g *boohu.Game, pos boohu.Position) (rune, termbox.Attribute, termbox.Attribute, bool) {
 	m := g.Dungeon
 	c := m.Cell(pos)
 	if !c.Explored && !g.Wizard {
 		if m.HasFreeExploredNeighbor(pos) {
-			termbox.SetCell(pos.X, pos.Y, '¤', ColorFgDark, ColorBgDark)
+			return '¤', ColorFgDark, ColorBgDark, true
 		}
-		return
+		return 0, 0, 0, false
 	}
 	if g.Wizard {
 		if !c.Explored && m.HasFreeExploredNeighbor(pos) {
-			termbox.SetCell(pos.X, pos.Y, '¤', ColorFgDark, ColorBgDark)
-			return
+			return '¤', ColorFgDark, ColorBgDark, true
 		}
-		if c.T == WallCell {
+		if c.T == boohu.WallCell {
 			if len(g.Dungeon.FreeNeighbors(pos)) == 0 {
-				return
+				return 0, 0, 0, false
 			}
 		}
 	}
@@ -760,9 +903,9 @@ func (ui *termui) DrawPosition(g *game, pos position) {
 	}
 	var r rune
 	switch c.T {
-	case WallCell:
+	case boohu.WallCell:
 		r = '#'
-	case FreeCell:
+	case boohu.FreeCell:
 		if g.UnknownDig[pos] {
 			r = '#'
 			break
@@ -792,14 +935,14 @@ func (ui *termui) DrawPosition(g *game, pos position) {
 				r = '$'
 				fgColor = ColorFgGold
 			}
-			m, _ := g.MonsterAt(pos)
+			m := g.MonsterAt(pos)
 			if m.Exists() && (g.Player.LOS[m.Pos] || g.Wizard) {
 				r = m.Kind.Letter()
-				if m.Status(MonsConfused) {
+				if m.Status(boohu.MonsConfused) {
 					fgColor = ColorFgConfusedMonster
-				} else if m.State == Resting {
+				} else if m.State == boohu.Resting {
 					fgColor = ColorFgSleepingMonster
-				} else if m.State == Wandering {
+				} else if m.State == boohu.Wandering {
 					fgColor = ColorFgWanderingMonster
 				} else {
 					fgColor = ColorFgMonster
@@ -807,17 +950,11 @@ func (ui *termui) DrawPosition(g *game, pos position) {
 			}
 		}
 	}
-	termbox.SetCell(pos.X, pos.Y, r, fgColor, bgColor)
+	return r, fgColor, bgColor, true
 }
 
-func (ui *termui) DrawStatusLine(g *game) {
-	sts := statusSlice{}
-	for st, c := range g.Player.Statuses {
-		if c > 0 {
-			sts = append(sts, st)
-		}
-	}
-	sort.Sort(sts)
+func (ui *termui) DrawStatusLine(g *boohu.Game) {
+	sts := g.SortedStatuses()
 	hpColor := termbox.Attribute(ColorFgHPok)
 	switch {
 	case g.Player.HP*100/g.Player.HPMax() < 30:
@@ -855,7 +992,7 @@ func (ui *termui) DrawStatusLine(g *game) {
 	}
 }
 
-func (ui *termui) DrawLog(g *game) {
+func (ui *termui) DrawLog(g *boohu.Game) {
 	min := len(g.Log) - 4
 	if min < 0 {
 		min = 0
@@ -865,9 +1002,15 @@ func (ui *termui) DrawLog(g *game) {
 	}
 }
 
-func (ui *termui) DrawPreviousLogs(g *game) {
-	lines := 23
-	nmax := len(g.Log) - lines
+func (ui *termui) DrawPreviousLogs(g *boohu.Game) {
+	ui.DrawPager(g.Log, func() termbox.Event { return ui.PollEvent(g) })
+}
+
+// DrawPager shows lines one screen at a time, starting from the end, with
+// keys for scrolling. It reads keys with poll.
+func (ui *termui) DrawPager(lines []string, poll func() termbox.Event) {
+	height := 23
+	nmax := len(lines) - height
 	n := nmax
 loop:
 	for {
@@ -878,18 +1021,18 @@ loop:
 		if n < 0 {
 			n = 0
 		}
-		to := n + lines
-		if to >= len(g.Log) {
-			to = len(g.Log)
+		to := n + height
+		if to >= len(lines) {
+			to = len(lines)
 		}
 		for i := n; i < to; i++ {
-			ui.DrawText(g.Log[i], 0, i-n)
+			ui.DrawText(lines[i], 0, i-n)
 		}
-		s := fmt.Sprintf("─────────(%d/%d)───────────────────────────────────────────────────────────────\n", len(g.Log)-to, len(g.Log))
+		s := fmt.Sprintf("─────────(%d/%d)───────────────────────────────────────────────────────────────\n", len(lines)-to, len(lines))
 		ui.DrawText(s, 0, to-n)
 		ui.DrawText("Keys: half-page up (u), half-page down (d), quit (esc or space)", 0, to+1-n)
 		termbox.Flush()
-		switch tev := termbox.PollEvent(); tev.Type {
+		switch tev := poll(); tev.Type {
 		case termbox.EventKey:
 			if tev.Ch == 0 {
 				switch tev.Key {
@@ -911,18 +1054,14 @@ loop:
 	}
 }
 
-func (ui *termui) DrawMonsterDescription(g *game, mons *monster) {
+func (ui *termui) DrawMonsterDescription(g *boohu.Game, mons *boohu.Monster) {
 	s := mons.Kind.Desc()
 	s += " " + fmt.Sprintf("They can hit for up to %d damage.", mons.Kind.BaseAttack())
 	s += " " + fmt.Sprintf("They have around %d HP.", mons.Kind.MaxHP())
 	ui.DrawDescription(g, s)
 }
 
-func (ui *termui) DrawConsumableDescription(g *game, c consumable) {
-	ui.DrawDescription(g, c.Desc())
-}
-
-func (ui *termui) DrawDescription(g *game, desc string) {
+func (ui *termui) DrawDescription(g *boohu.Game, desc string) {
 	termbox.Clear(ColorFg, ColorBg)
 	desc = formatText(desc, 79)
 	lines := strings.Count(desc, "\n")
@@ -949,7 +1088,7 @@ func (ui *termui) DrawColoredText(text string, x, y int, color termbox.Attribute
 	}
 }
 
-func (ui *termui) SelectProjectile(g *game, ev event) error {
+func (ui *termui) SelectProjectile(g *boohu.Game, ev boohu.Event) (boohu.Action, error) {
 	desc := false
 	for {
 		termbox.Clear(ColorFg, ColorBg)
@@ -968,23 +1107,22 @@ func (ui *termui) SelectProjectile(g *game, ev event) error {
 			desc = !desc
 			continue
 		}
-		if noAction == nil {
-			if desc {
-				ui.DrawDescription(g, cs[index].Desc())
-				continue
-			}
-			b := ui.ChooseTarget(g, &chooser{single: true})
-			if b {
-				noAction = cs[index].Use(g, ev)
-			} else {
-				noAction = errors.New("Ok, then.")
-			}
+		if noAction != nil {
+			return boohu.Action{}, noAction
+		}
+		if desc {
+			ui.DrawDescription(g, cs[index].Desc())
+			continue
+		}
+		p := cs[index]
+		if !ui.ChooseTarget(g, p.Targetter()) {
+			return boohu.Action{}, errors.New("Ok, then.")
 		}
-		return noAction
+		return boohu.Action{Kind: boohu.ThrowAction, Projectile: p, Target: g.Player.Target}, nil
 	}
 }
 
-func (ui *termui) SelectPotion(g *game, ev event) error {
+func (ui *termui) SelectPotion(g *boohu.Game, ev boohu.Event) (boohu.Action, error) {
 	desc := false
 	for {
 		termbox.Clear(ColorFg, ColorBg)
@@ -1003,18 +1141,18 @@ func (ui *termui) SelectPotion(g *game, ev event) error {
 			desc = !desc
 			continue
 		}
-		if noAction == nil {
-			if desc {
-				ui.DrawDescription(g, cs[index].Desc())
-				continue
-			}
-			noAction = cs[index].Use(g, ev)
+		if noAction != nil {
+			return boohu.Action{}, noAction
 		}
-		return noAction
+		if desc {
+			ui.DrawDescription(g, cs[index].Desc())
+			continue
+		}
+		return boohu.Action{Kind: boohu.QuaffAction, Potion: cs[index]}, nil
 	}
 }
 
-func (ui *termui) SelectRod(g *game, ev event) error {
+func (ui *termui) SelectRod(g *boohu.Game, ev boohu.Event) (boohu.Action, error) {
 	desc := false
 	for {
 		termbox.Clear(ColorFg, ColorBg)
@@ -1034,21 +1172,35 @@ func (ui *termui) SelectRod(g *game, ev event) error {
 			desc = !desc
 			continue
 		}
-		if noAction == nil {
-			if desc {
-				ui.DrawDescription(g, rs[index].Desc())
-				continue
+		if noAction != nil {
+			ui.DrawDungeonView(g, false)
+			return boohu.Action{}, noAction
+		}
+		if desc {
+			ui.DrawDescription(g, rs[index].Desc())
+			continue
+		}
+		r := rs[index]
+		action := boohu.Action{Kind: boohu.EvokeAction, Rod: r}
+		if err := r.CanEvoke(g); err != nil {
+			ui.DrawDungeonView(g, false)
+			return action, err
+		}
+		if targ := r.Targetter(); targ != nil {
+			if !ui.ChooseTarget(g, targ) {
+				ui.DrawDungeonView(g, false)
+				return action, errors.New("Ok, then.")
 			}
-			noAction = rs[index].Use(g, ev)
+			action.Target = g.Player.Target
 		}
 		ui.DrawDungeonView(g, false)
-		return noAction
+		return action, nil
 	}
 }
 
-func (ui *termui) Select(g *game, ev event, l int) (index int, alternate bool, err error) {
+func (ui *termui) Select(g *boohu.Game, ev boohu.Event, l int) (index int, alternate bool, err error) {
 	for {
-		switch tev := termbox.PollEvent(); tev.Type {
+		switch tev := ui.PollEvent(g); tev.Type {
 		case termbox.EventKey:
 			if tev.Ch == 0 {
 				switch tev.Key {
@@ -1066,34 +1218,69 @@ func (ui *termui) Select(g *game, ev event, l int) (index int, alternate bool, e
 	}
 }
 
-func (ui *termui) ExploreStep(g *game) bool {
+func (ui *termui) ExploreStep(g *boohu.Game) bool {
+	if ui.replayer != nil {
+		in := ui.ReplayInput(g)
+		ui.DrawDungeonView(g, false)
+		return in.Kind == boohu.ExploreStopInput
+	}
+	if ui.HungUp() {
+		// stop, so that the game is saved at the player's turn
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.ExploreStopInput})
+		return true
+	}
 	next := make(chan bool)
 	go func() {
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(ui.config.ExploreDelay)
 		next <- false
 	}()
 	if runtime.GOOS != "windows" {
 		// strange bug it seems, cannot test myself, so disable on windows
 		go func() {
 			ui.PressAnyKey()
+			if ui.HungUp() {
+				// the watcher of a previous step may have consumed the
+				// interrupt meant for the event loop: pass it on
+				termbox.Interrupt()
+			}
 			next <- true
 		}()
 	}
 	stop := <-next
+	if stop {
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.ExploreStopInput})
+	} else {
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.ExploreInput})
+	}
 	ui.DrawDungeonView(g, false)
 	return stop
 }
 
-func (ui *termui) Death(g *game) {
+func (ui *termui) RestStep(g *boohu.Game) bool {
+	if ui.replayer != nil {
+		return ui.ReplayRestStop()
+	}
+	if ui.HungUp() {
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.RestStopInput})
+		return true
+	}
+	return false
+}
+
+func (ui *termui) Death(g *boohu.Game) {
 	g.Print("You die... --press esc or space to continue--")
 	ui.DrawDungeonView(g, false)
 	ui.WaitForContinue(g)
-	ui.Dump(g)
-	g.WriteDump()
+	ui.WriteMorgue(g)
+	g.WriteStats()
+	rank, _ := g.WriteHighScore()
+	dailyRank, _ := g.WriteDailyScore()
+	ui.Dump(g, rank, dailyRank)
+	g.WriteReplay()
 	ui.WaitForContinue(g)
 }
 
-func (ui *termui) Win(g *game) {
+func (ui *termui) Win(g *boohu.Game) {
 	if g.Wizard {
 		g.Print("You escape by the magic stairs! **WIZARD** --press esc or space to continue--")
 	} else {
@@ -1101,28 +1288,42 @@ func (ui *termui) Win(g *game) {
 	}
 	ui.DrawDungeonView(g, false)
 	ui.WaitForContinue(g)
-	ui.Dump(g)
-	g.WriteDump()
+	ui.WriteMorgue(g)
+	g.WriteStats()
+	rank, _ := g.WriteHighScore()
+	dailyRank, _ := g.WriteDailyScore()
+	ui.Dump(g, rank, dailyRank)
+	g.WriteReplay()
 	ui.WaitForContinue(g)
 }
 
-func (ui *termui) Dump(g *game) {
+// Dump shows the summary of a finished game, which entered the high-score
+// table at rank if not zero, and the leaderboard of the daily challenge at
+// dailyRank if not zero.
+func (ui *termui) Dump(g *boohu.Game, rank, dailyRank int) {
 	termbox.Clear(ColorFg, ColorBg)
-	ui.DrawText(g.SimplifedDump(), 0, 0)
+	text := g.SimplifedDump()
+	if dailyRank > 0 {
+		text = fmt.Sprintf("You are number %d in the daily challenge of %s.\n\n", dailyRank, g.Daily) + text
+	}
+	if rank > 0 {
+		text = fmt.Sprintf("New high score! You are number %d in the high-score table.\n\n", rank) + text
+	}
+	ui.DrawText(text, 0, 0)
 	termbox.Flush()
 }
 
-func (ui *termui) CriticalHPWarning(g *game) {
+func (ui *termui) CriticalHPWarning(g *boohu.Game) {
 	g.Print("*** CRITICAL HP WARNING *** --press esc or space to continue--")
 	ui.DrawDungeonView(g, false)
 	ui.WaitForContinue(g)
 	g.Print("Ok. Be careful, then.")
 }
 
-func (ui *termui) WaitForContinue(g *game) {
+func (ui *termui) WaitForContinue(g *boohu.Game) {
 loop:
 	for {
-		switch tev := termbox.PollEvent(); tev.Type {
+		switch tev := ui.PollEvent(g); tev.Type {
 		case termbox.EventKey:
 			if tev.Ch == 0 {
 				switch tev.Key {
@@ -1134,34 +1335,66 @@ loop:
 	}
 }
 
-func (ui *termui) Quit(g *game) bool {
+func (ui *termui) Quit(g *boohu.Game) bool {
+	if !ui.config.ConfirmQuit {
+		// replays ask for confirmation, as by default
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.KeyInput, Ch: 'Y'})
+		return true
+	}
 	g.Print("Do you really want to quit without saving? (capital 'Y' to confirm)")
 	ui.DrawDungeonView(g, false)
 	return ui.PromptConfirmation(g)
 }
 
-func (ui *termui) Wizard(g *game) bool {
+func (ui *termui) Wizard(g *boohu.Game) bool {
 	g.Print("Do you really want to enter wizard mode (no return)? (capital 'Y' to confirm)")
 	ui.DrawDungeonView(g, false)
 	return ui.PromptConfirmation(g)
 }
 
-func (ui *termui) PromptConfirmation(g *game) bool {
+func (ui *termui) PromptConfirmation(g *boohu.Game) bool {
+	for {
+		switch tev := ui.PollEvent(g); tev.Type {
+		case termbox.EventKey:
+			return tev.Ch == 'Y'
+		}
+	}
+}
+
+// Confirm asks a question outside of any game, so that the answer is not
+// recorded.
+func (ui *termui) Confirm(question string) bool {
+	termbox.Clear(ColorFg, ColorBg)
+	ui.DrawText(formatText(question, 79), 0, 0)
+	termbox.Flush()
 	for {
 		switch tev := termbox.PollEvent(); tev.Type {
 		case termbox.EventKey:
-			if tev.Ch == 'Y' {
-				return true
-			}
+			return tev.Ch == 'Y'
 		}
-		return false
 	}
 }
 
+func (ui *termui) PollEvent(g *boohu.Game) termbox.Event {
+	if ui.replayer != nil {
+		return ui.ReplayEvent(g)
+	}
+	tev := termbox.PollEvent()
+	if ui.HungUp() {
+		// get out of any menu, so that the game can be saved; the key is not
+		// recorded, as the player did not type it
+		return termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}
+	}
+	if tev.Type == termbox.EventKey {
+		g.RecordInput(boohu.ReplayInput{Kind: boohu.KeyInput, Ch: tev.Ch, Key: uint16(tev.Key)})
+	}
+	return tev
+}
+
 func (ui *termui) PressAnyKey() {
 	for {
 		switch tev := termbox.PollEvent(); tev.Type {
-		case termbox.EventKey:
+		case termbox.EventKey, termbox.EventInterrupt:
 			return
 		}
 	}
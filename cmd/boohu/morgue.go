@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// maxMorgueEntries is the number of past games listed in the morgue screen.
+const maxMorgueEntries = 20
+
+// MorgueScreen lists the dumps of past games and shows the chosen ones. The
+// keys are not recorded, as the morgue is not part of the game.
+func (ui *termui) MorgueScreen(g *boohu.Game) {
+	entries, err := g.Morgues()
+	if err != nil {
+		g.Print(err.Error())
+		return
+	}
+	if len(entries) == 0 {
+		g.Print("There are no dumps of past games yet.")
+		return
+	}
+	dir, _ := g.MorgueDir()
+	for {
+		termbox.Clear(ColorFg, ColorBg)
+		ui.DrawText("Dumps of past games (esc or space to return to the game)", 0, 0)
+		line := 2
+		for i, entry := range entries {
+			if i >= maxMorgueEntries {
+				ui.DrawText(fmt.Sprintf("… and %d older dumps in %s", len(entries)-i, dir), 0, line)
+				break
+			}
+			ui.DrawText(fmt.Sprintf("%c - %s", rune(i+97), MorgueEntryDescription(entry)), 0, line)
+			line++
+		}
+		termbox.Flush()
+		tev := ui.PollUnrecorded()
+		if tev.Type != termbox.EventKey {
+			continue
+		}
+		if tev.Ch == 0 && (tev.Key == termbox.KeyEsc || tev.Key == termbox.KeySpace) {
+			return
+		}
+		i := int(tev.Ch - 97)
+		if 0 <= i && i < len(entries) && i < maxMorgueEntries {
+			data, err := ioutil.ReadFile(entries[i].File)
+			if err != nil {
+				g.Print(err.Error())
+				return
+			}
+			ui.DrawPager(strings.Split(string(data), "\n"), ui.PollUnrecorded)
+		}
+	}
+}
+
+// WriteMorgue archives the dumps of a finished game in the morgue, along with
+// an HTML dump.
+func (ui *termui) WriteMorgue(g *boohu.Game) {
+	err := g.WriteMorgue()
+	if err != nil || g.MorgueFile() == "" {
+		return
+	}
+	ui.WriteHTMLDump(g, strings.TrimSuffix(g.MorgueFile(), ".txt")+".html")
+}
+
+func MorgueEntryDescription(entry boohu.MorgueEntry) string {
+	name := entry.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	return fmt.Sprintf("%s %s, %s", name, entry.Outcome, entry.Time.Format("2006-01-02 15:04"))
+}
+
+// PollUnrecorded waits for an event that is not recorded for the replay. It
+// returns esc if the game has to be saved and closed.
+func (ui *termui) PollUnrecorded() termbox.Event {
+	tev := termbox.PollEvent()
+	if tev.Type == termbox.EventInterrupt && ui.HungUp() {
+		tev = termbox.Event{Type: termbox.EventKey, Key: termbox.KeyEsc}
+	}
+	return tev
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// replayer plays back a recorded game in the terminal, with player controls
+// for pausing, stepping and speed.
+type replayer struct {
+	*boohu.Replay
+	index    int
+	delay    time.Duration
+	paused   bool
+	controls chan termbox.Event
+}
+
+func (ui *termui) Replay(rep *boohu.Replay) {
+	// replays record the default keys of commands, and the confirmation of
+	// quitting
+	ui.config.bindings = nil
+	ui.config.examineBinds = nil
+	ui.config.bindKeys("")
+	ui.config.ConfirmQuit = DefaultConfig().ConfirmQuit
+	ui.replayer = &replayer{
+		Replay:   rep,
+		delay:    100 * time.Millisecond,
+		controls: make(chan termbox.Event),
+	}
+	go func() {
+		for {
+			tev := termbox.PollEvent()
+			if tev.Type == termbox.EventKey {
+				ui.replayer.controls <- tev
+			}
+		}
+	}()
+	g := boohu.NewReplayGame(rep, ui)
+	g.EventLoop()
+	ui.EndReplay(g)
+}
+
+// ReplayRestStop reports whether the recorded game stopped resting at this
+// turn.
+func (ui *termui) ReplayRestStop() bool {
+	rp := ui.replayer
+	if rp.index < len(rp.Inputs) && rp.Inputs[rp.index].Kind == boohu.RestStopInput {
+		rp.index++
+		return true
+	}
+	return false
+}
+
+func (ui *termui) ReplayInput(g *boohu.Game) boohu.ReplayInput {
+	rp := ui.replayer
+	for {
+		if rp.index >= len(rp.Inputs) {
+			ui.EndReplay(g)
+		}
+		if in := rp.Inputs[rp.index]; in.Kind == boohu.CriticalHPInput {
+			// a setting changed by the player, not an input to wait for
+			g.CriticalHP = in.HP
+			rp.index++
+			continue
+		}
+		var tev termbox.Event
+		if rp.paused {
+			ui.DrawDungeonView(g, false)
+			tev = <-rp.controls
+		} else {
+			select {
+			case tev = <-rp.controls:
+			case <-time.After(rp.delay):
+				rp.index++
+				return rp.Inputs[rp.index-1]
+			}
+		}
+		if tev.Ch == 0 {
+			switch tev.Key {
+			case termbox.KeyEsc:
+				tev.Ch = 'q'
+			case termbox.KeySpace:
+				tev.Ch = 'p'
+			}
+		}
+		switch tev.Ch {
+		case 'p':
+			rp.paused = !rp.paused
+		case 'n', '.':
+			if rp.paused {
+				rp.index++
+				return rp.Inputs[rp.index-1]
+			}
+		case '+':
+			if rp.delay > 10*time.Millisecond {
+				rp.delay /= 2
+			}
+		case '-':
+			if rp.delay < 2*time.Second {
+				rp.delay *= 2
+			}
+		case 'q':
+			ui.QuitReplay()
+		}
+	}
+}
+
+func (ui *termui) ReplayEvent(g *boohu.Game) termbox.Event {
+	for {
+		in := ui.ReplayInput(g)
+		if in.Kind == boohu.KeyInput {
+			return termbox.Event{Type: termbox.EventKey, Ch: in.Ch, Key: termbox.Key(in.Key)}
+		}
+	}
+}
+
+func (ui *termui) EndReplay(g *boohu.Game) {
+	g.Print("End of replay. --press esc or space to quit--")
+	ui.DrawDungeonView(g, false)
+loop:
+	for {
+		tev := <-ui.replayer.controls
+		if tev.Ch == 0 {
+			switch tev.Key {
+			case termbox.KeyEsc, termbox.KeySpace:
+				break loop
+			}
+		}
+	}
+	ui.QuitReplay()
+}
+
+func (ui *termui) QuitReplay() {
+	termbox.Close()
+	os.Exit(0)
+}
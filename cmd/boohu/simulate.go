@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/anaseto/boohu"
+)
+
+// maxSimulationTurns stops games in which the bot got stuck.
+const maxSimulationTurns = 100000
+
+// Simulate plays n games with the baseline bot and prints a summary of the
+// results. Games use consecutive seeds starting from seed.
+func Simulate(n int, seed int64) {
+	wins := 0
+	unfinished := 0
+	deaths := map[int]int{}
+	killers := map[string]int{}
+	for i := 0; i < n; i++ {
+		res := boohu.PlayBot(boohu.BaselineBot{}, seed+int64(i), maxSimulationTurns)
+		switch res.Outcome {
+		case boohu.Won:
+			wins++
+		case boohu.Died:
+			deaths[res.Depth]++
+			killers[res.Killer]++
+		default:
+			unfinished++
+		}
+	}
+	fmt.Printf("Games: %d (seeds %d to %d)\n", n, seed, seed+int64(n-1))
+	fmt.Printf("Win rate: %.1f%% (%d)\n", percent(wins, n), wins)
+	if unfinished > 0 {
+		fmt.Printf("Unfinished: %.1f%% (%d)\n", percent(unfinished, n), unfinished)
+	}
+	died := n - wins - unfinished
+	if died == 0 {
+		return
+	}
+	fmt.Printf("\nDeath depth:\n")
+	depths := []int{}
+	for depth := range deaths {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	for _, depth := range depths {
+		fmt.Printf("  %2d: %5.1f%% (%d)\n", depth, percent(deaths[depth], died), deaths[depth])
+	}
+	fmt.Printf("\nKillers:\n")
+	names := []string{}
+	for name := range killers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if killers[names[i]] != killers[names[j]] {
+			return killers[names[i]] > killers[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		fmt.Printf("  %-20s %5.1f%% (%d)\n", name, percent(killers[name], died), killers[name])
+	}
+}
+
+func percent(count, total int) float64 {
+	return 100 * float64(count) / float64(total)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// maxNameLength is the maximum length of a character's name.
+const maxNameLength = 20
+
+// slotLetters are the keys of the saved games of a page of the save slots
+// screen, n being the key for a new game.
+const slotLetters = "abcdefghijklmopqrstu"
+
+// ChooseCharacter lets the player continue the saved game of a character or
+// name a new one. Starting fresh with the name of a character that has a
+// saved game abandons that game. It returns false if the player quit instead.
+func (ui *termui) ChooseCharacter(g *boohu.Game) bool {
+	slots, _ := g.SaveSlots()
+loop:
+	for {
+		if len(slots) > 0 {
+			i, ok := ui.SelectSaveSlot(g, slots)
+			if !ok {
+				return false
+			}
+			if i >= 0 {
+				g.Name = slots[i].Name
+				return true
+			}
+		}
+		name, ok := ui.PromptName(g)
+		if !ok {
+			if len(slots) == 0 {
+				return false
+			}
+			continue loop
+		}
+		for _, slot := range slots {
+			if slot.Name == name && !ui.Confirm(fmt.Sprintf("%s already has a saved game. Do you really want to abandon it and start a new game? (capital 'Y' to confirm)", name)) {
+				continue loop
+			}
+		}
+		g.Name = name
+		g.RemoveSaveFile()
+		return true
+	}
+}
+
+// SelectSaveSlot shows the saved games, a page at a time, and returns the
+// index of the chosen one, or -1 if the player wants to start a new game. It
+// returns false if the player quit.
+func (ui *termui) SelectSaveSlot(g *boohu.Game, slots []boohu.SaveSlot) (int, bool) {
+	n := len(slotLetters)
+	pages := (len(slots) + n - 1) / n
+	page := 0
+	for {
+		termbox.Clear(ColorFg, ColorBg)
+		ui.DrawText("Continue which game? (n to start a new game, tab to view the high scores, esc to quit)", 0, 0)
+		for i := page * n; i < len(slots) && i < (page+1)*n; i++ {
+			ui.DrawText(fmt.Sprintf("%c - %s", slotLetters[i-page*n], SaveSlotDescription(slots[i])), 0, i-page*n+2)
+		}
+		if pages > 1 {
+			ui.DrawText(fmt.Sprintf("Page %d/%d (> and < to change page)", page+1, pages), 0, n+3)
+		}
+		termbox.Flush()
+		tev := termbox.PollEvent()
+		if tev.Type != termbox.EventKey {
+			continue
+		}
+		switch {
+		case tev.Ch == 'n':
+			return -1, true
+		case tev.Ch == '>':
+			if page < pages-1 {
+				page++
+			}
+			continue
+		case tev.Ch == '<':
+			if page > 0 {
+				page--
+			}
+			continue
+		case tev.Ch == 0 && tev.Key == termbox.KeyEsc:
+			return -1, false
+		case tev.Key == termbox.KeyTab:
+			ui.HighScoreScreen(g)
+			continue
+		}
+		if i := strings.IndexRune(slotLetters, tev.Ch); tev.Ch != 0 && i >= 0 && page*n+i < len(slots) {
+			return page*n + i, true
+		}
+	}
+}
+
+func SaveSlotDescription(slot boohu.SaveSlot) string {
+	name := slot.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	if slot.Err != nil {
+		return fmt.Sprintf("%s: damaged save", name)
+	}
+	desc := fmt.Sprintf("%s: depth %d, %.1f turns, last played %s",
+		name, slot.Depth, float64(slot.Turn)/10, slot.LastPlayed.Format("2006-01-02 15:04"))
+	if slot.Daily != "" {
+		desc += fmt.Sprintf(", daily challenge of %s", slot.Daily)
+	}
+	switch {
+	case slot.Unfinished:
+		desc += " (did not end properly)"
+	case slot.AutoSaved:
+		desc += " (saved when the terminal was closed)"
+	}
+	return desc
+}
+
+// PromptName asks the name of a new character. It returns false if the
+// player cancelled.
+func (ui *termui) PromptName(g *boohu.Game) (string, bool) {
+	name := []rune{}
+	for {
+		termbox.Clear(ColorFg, ColorBg)
+		prompt := "What is your name? "
+		ui.DrawText(prompt+string(name), 0, 0)
+		ui.DrawText("(letters, digits, “-” and “_”, enter to confirm, esc to cancel)", 0, 2)
+		ui.DrawText("(tab to view the high scores)", 0, 3)
+		termbox.SetCursor(len(prompt)+len(name), 0)
+		termbox.Flush()
+		tev := termbox.PollEvent()
+		if tev.Type != termbox.EventKey {
+			continue
+		}
+		switch tev.Key {
+		case termbox.KeyEnter:
+			if len(name) > 0 {
+				termbox.HideCursor()
+				return string(name), true
+			}
+			continue
+		case termbox.KeyEsc:
+			termbox.HideCursor()
+			return "", false
+		case termbox.KeyTab:
+			termbox.HideCursor()
+			ui.HighScoreScreen(g)
+			continue
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if len(name) > 0 {
+				name = name[:len(name)-1]
+			}
+			continue
+		}
+		c := tev.Ch
+		if len(name) < maxNameLength && ('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_') {
+			name = append(name, c)
+		}
+	}
+}
+
+// HighScoreScreen shows the high-score table. The d key switches to the
+// leaderboard of today's daily challenge.
+func (ui *termui) HighScoreScreen(g *boohu.Game) {
+	daily := false
+	for {
+		var scores []boohu.HighScore
+		var err error
+		var title string
+		if daily {
+			date := boohu.DailyDate(time.Now())
+			scores, err = g.DailyScores(date)
+			title = fmt.Sprintf("Daily challenge of %s (press d for the high scores)", date)
+		} else {
+			scores, err = g.HighScores()
+			title = "High scores, wizard games are not recorded (press d for the daily challenge)"
+		}
+		ui.DrawHighScores(title, scores, err)
+		tev := ui.PollUnrecorded()
+		if tev.Type != termbox.EventKey {
+			continue
+		}
+		if tev.Ch == 0 && (tev.Key == termbox.KeyEsc || tev.Key == termbox.KeySpace) {
+			return
+		}
+		if tev.Ch == 'd' {
+			daily = !daily
+		}
+	}
+}
+
+func (ui *termui) DrawHighScores(title string, scores []boohu.HighScore, err error) {
+	termbox.Clear(ColorFg, ColorBg)
+	ui.DrawText(title, 0, 0)
+	switch {
+	case err != nil:
+		ui.DrawText(fmt.Sprintf("Could not read the high scores: %v", err), 0, 2)
+	case len(scores) == 0:
+		ui.DrawText("No game has ended yet.", 0, 2)
+	default:
+		ui.DrawText(fmt.Sprintf("%3s %6s  %-*s %5s %6s  %s", "", "Score", maxNameLength, "Name", "Depth", "Turns", "Outcome"), 0, 2)
+		for i, hs := range scores {
+			if i >= 20 {
+				break
+			}
+			ui.DrawText(fmt.Sprintf("%3d %6d  %-*s %5d %6d  %s", i+1, hs.Score, maxNameLength, hs.Name, hs.Depth, hs.Turns, HighScoreOutcome(hs)), 0, i+3)
+		}
+	}
+	ui.DrawText("───Press esc or space to continue───", 0, 23)
+	termbox.Flush()
+}
+
+func HighScoreOutcome(hs boohu.HighScore) string {
+	switch {
+	case hs.Outcome == boohu.Won:
+		return fmt.Sprintf("escaped on %s", hs.Date.Format("2006-01-02"))
+	case hs.KilledBy() != "":
+		return fmt.Sprintf("killed by %s on %s", hs.KilledBy(), hs.Date.Format("2006-01-02"))
+	default:
+		return fmt.Sprintf("died on %s", hs.Date.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/anaseto/boohu"
+)
+
+// maxStatsCounts is the number of monsters listed in the statistics of
+// deadliest and killed monsters.
+const maxStatsCounts = 10
+
+// StatsSummary returns the lines of the summary of the statistics of past
+// games.
+func StatsSummary(s boohu.Stats) []string {
+	if s.Games == 0 && s.Abandoned == 0 {
+		return []string{"No game has been finished yet."}
+	}
+	lines := []string{
+		fmt.Sprintf("Games: %d", s.Games),
+		fmt.Sprintf("Win rate: %.1f%% (%d)", s.WinRate(), s.Wins),
+	}
+	if s.Abandoned > 0 {
+		lines = append(lines, fmt.Sprintf("Abandoned games: %d (not counted in the games)", s.Abandoned))
+	}
+	if s.Deaths > 0 {
+		lines = append(lines, fmt.Sprintf("Average death depth: %.1f", s.AverageDeathDepth()))
+	}
+	lines = appendCounts(lines, "Deadliest monsters:", s.Killers, maxStatsCounts)
+	lines = appendCounts(lines, "Other causes of death:", s.Causes, 0)
+	lines = appendCounts(lines, "Most killed monsters:", s.Kills, maxStatsCounts)
+	lines = appendCounts(lines, "Potions and projectiles used:", s.Used, 0)
+	lines = appendCounts(lines, "Rods evoked:", s.Evoked, 0)
+	lines = appendCounts(lines, "Aptitudes gained (games):", s.Aptitudes, 0)
+	return lines
+}
+
+// appendCounts appends a titled list of the counts of m to lines, with at
+// most max entries if not zero.
+func appendCounts(lines []string, title string, m map[string]int, max int) []string {
+	counts := boohu.SortCounts(m)
+	if len(counts) == 0 {
+		return lines
+	}
+	lines = append(lines, "", title)
+	for i, c := range counts {
+		if max > 0 && i >= max {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("  %5d %s", c.Count, c.Name))
+	}
+	return lines
+}
+
+// StatsScreen shows the statistics of past games. The keys are not recorded,
+// as the statistics are not part of the game.
+func (ui *termui) StatsScreen(g *boohu.Game) {
+	s, err := g.Stats()
+	if err != nil {
+		g.Print(err.Error())
+		return
+	}
+	lines := append([]string{"Statistics of past games", ""}, StatsSummary(s)...)
+	ui.DrawPager(lines, ui.PollUnrecorded)
+}
+
+// PrintStats prints the statistics of past games to the standard output.
+func PrintStats() {
+	g := boohu.NewGame(nil)
+	s, err := g.Stats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading statistics: %v\n", err)
+		os.Exit(1)
+	}
+	for _, line := range StatsSummary(s) {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/anaseto/boohu"
+	termbox "github.com/nsf/termbox-go"
+)
+
+// WizardConsole reads and runs commands of the wizard console until the
+// player cancels with esc. Keys are recorded, so that replays run the same
+// commands. The up arrow recalls the previous command. It returns true if the
+// game was rewound, in which case the turn ev is over.
+func (ui *termui) WizardConsole(g *boohu.Game, ev boohu.Event) bool {
+	g.Print("Wizard console: type help for the commands, esc to return to the game.")
+	line := []rune{}
+	last := ""
+	for {
+		ui.DrawDungeonView(g, false)
+		// the prompt takes the place of the oldest message
+		blank := strings.Repeat(" ", g.Dungeon.Width)
+		for i := 0; i < 4; i++ {
+			ui.DrawText(blank, 0, g.Dungeon.Heigth+1+i)
+		}
+		min := len(g.Log) - 3
+		if min < 0 {
+			min = 0
+		}
+		for i, s := range g.Log[min:] {
+			ui.DrawText(s, 0, g.Dungeon.Heigth+1+i)
+		}
+		y := g.Dungeon.Heigth + 1 + len(g.Log[min:])
+		prompt := "wizard> " + string(line)
+		ui.DrawText(prompt, 0, y)
+		termbox.SetCursor(len([]rune(prompt)), y)
+		termbox.Flush()
+		tev := ui.PollEvent(g)
+		if tev.Type != termbox.EventKey {
+			continue
+		}
+		switch tev.Key {
+		case termbox.KeyEsc:
+			termbox.HideCursor()
+			ui.DrawDungeonView(g, false)
+			return false
+		case termbox.KeyEnter:
+			if len(line) == 0 {
+				continue
+			}
+			last = string(line)
+			line = line[:0]
+			msg, err := g.WizardCommand(last, ev)
+			if err != nil {
+				msg = err.Error()
+			}
+			for _, s := range strings.Split(strings.TrimSpace(formatText(msg, g.Dungeon.Width-1)), "\n") {
+				g.Print(s)
+			}
+			if g.Rewound() {
+				termbox.HideCursor()
+				return true
+			}
+			continue
+		case termbox.KeyArrowUp:
+			line = []rune(last)
+			continue
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+			continue
+		case termbox.KeySpace:
+			tev.Ch = ' '
+		}
+		if tev.Ch != 0 && len(line) < g.Dungeon.Width-10 {
+			line = append(line, tev.Ch)
+		}
+	}
+}
@@ -1,27 +1,130 @@
 // combat utility functions
 
-package main
+package boohu
 
-func (g *game) HitDamage(base int, armor int) int {
+import "fmt"
+
+func (g *Game) HitDamage(base int, armor int) int {
 	min := base / 2
-	attack := min + RandInt(base-min+1)
-	attack -= RandInt(armor + 1)
+	attack := min + g.Rand.Int(base-min+1)
+	attack -= g.Rand.Int(armor + 1)
 	if attack < 0 {
 		attack = 0
 	}
 	return attack
 }
 
-func (m *monster) InflictDamage(g *game, damage, max int) {
+// attack is the way the player was damaged. NoAttack is for high scores of
+// older versions, which did not record it.
+type attack int
+
+const (
+	NoAttack attack = iota
+	MeleeAttack
+	RockAttack
+	JavelinAttack
+	TormentBoltAttack
+	ExplosionAttack
+	UnseenAttack
+)
+
+func (a attack) String() (text string) {
+	switch a {
+	case MeleeAttack:
+		text = "melee attack"
+	case RockAttack:
+		text = "rock"
+	case JavelinAttack:
+		text = "javelin"
+	case TormentBoltAttack:
+		text = "bolt of torment"
+	case ExplosionAttack:
+		text = "explosion"
+	case UnseenAttack:
+		text = "unseen attack"
+	}
+	return text
+}
+
+// killedBy describes what dealt a hit: source is the attacking monster, or
+// an empty string when no monster was involved.
+func killedBy(source string, a attack) string {
+	switch {
+	case a == ExplosionAttack:
+		return "an explosion"
+	case a == UnseenAttack:
+		return "something unseen"
+	case a == NoAttack:
+		return source
+	default:
+		return fmt.Sprintf("%s's %s", source, a)
+	}
+}
+
+// DamageEvent records a hit taken by the player.
+type DamageEvent struct {
+	Source  string // attacking monster, with an indefinite article
+	Monster string // kind of the attacking monster, empty for other causes
+	Attack  attack
+	Damage  int
+	HP      int // HP before the hit
+	Depth   int
+	Turn    int
+}
+
+func (d DamageEvent) String() string {
+	return fmt.Sprintf("Depth %2d|Turn %7.1f| %s: %d damage (%d HP before)",
+		d.Depth, float64(d.Turn)/10, killedBy(d.Source, d.Attack), d.Damage, d.HP)
+}
+
+// Cause describes what dealt the hit.
+func (d DamageEvent) Cause() string {
+	return killedBy(d.Source, d.Attack)
+}
+
+// maxDamageEvents is the number of hits remembered for the dump.
+const maxDamageEvents = 5
+
+// TakeDamage records a hit taken by the player, which had hp before it, and
+// the cause of death if the hit was deadly. The attacking monster mons is nil
+// when the hit was not dealt by a monster.
+func (g *Game) TakeDamage(mons *Monster, a attack, damage, hp int) {
+	d := DamageEvent{Attack: a, Damage: damage, HP: hp, Depth: g.Depth, Turn: g.Turn}
+	if mons != nil {
+		d.Monster = mons.Kind.String()
+		d.Source = Indefinite(d.Monster, false)
+	}
+	g.Damages = append(g.Damages, d)
+	if len(g.Damages) > maxDamageEvents {
+		g.Damages = g.Damages[len(g.Damages)-maxDamageEvents:]
+	}
+	if g.Player.HP > 0 {
+		return
+	}
+	g.KilledBy = d
+	switch a {
+	case ExplosionAttack, UnseenAttack:
+		g.Killer = d.Cause()
+	default:
+		g.Killer = d.Source
+	}
+	g.StoryPrintf("Killed by %s (%d damage, %d HP before the hit)", d.Cause(), damage, hp)
+}
+
+func (m *Monster) InflictDamage(g *Game, a attack, damage, max int) {
 	oldHP := g.Player.HP
 	g.Player.HP -= damage
+	g.TakeDamage(m, a, damage, oldHP)
+	if g.CriticalHP > 0 {
+		max = g.CriticalHP
+	}
 	if oldHP > max && g.Player.HP <= max {
 		g.StoryPrintf("Critical HP: %d (hit by %s)", g.Player.HP, Indefinite(m.Kind.String(), false))
 		g.ui.CriticalHPWarning(g)
 	}
 }
 
-func (g *game) MakeMonstersAware() {
+func (g *Game) MakeMonstersAware() {
 	for _, m := range g.Monsters {
 		if m.HP <= 0 {
 			continue
@@ -35,9 +138,10 @@ func (g *game) MakeMonstersAware() {
 	}
 }
 
-func (g *game) MakeNoise(noise int, at position) {
+func (g *Game) MakeNoise(noise int, at Position) {
 	dij := &normalPath{game: g}
-	nm := Dijkstra(dij, []position{at}, noise)
+	nm := Dijkstra(dij, []Position{at}, noise)
+	g.noiseMap, g.noiseLevel = nm, noise
 	for _, m := range g.Monsters {
 		if !m.Exists() {
 			continue
@@ -58,7 +162,7 @@ func (g *game) MakeNoise(noise int, at position) {
 		if v > 90 {
 			v = 90
 		}
-		r := RandInt(100)
+		r := g.Rand.Int(100)
 		if m.State == Resting {
 			r += 10
 		}
@@ -74,17 +178,17 @@ func (g *game) MakeNoise(noise int, at position) {
 	}
 }
 
-func (g *game) AttackMonster(mons *monster) {
+func (g *Game) AttackMonster(mons *Monster) {
 	switch {
 	case g.Player.Weapon.Cleave():
-		var neighbors []position
+		var neighbors []Position
 		if g.Player.HasStatus(StatusConfusion) {
 			neighbors = g.Dungeon.CardinalFreeNeighbors(g.Player.Pos)
 		} else {
 			neighbors = g.Dungeon.FreeNeighbors(g.Player.Pos)
 		}
 		for _, pos := range neighbors {
-			mons, _ := g.MonsterAt(pos)
+			mons := g.MonsterAt(pos)
 			if mons.Exists() {
 				g.HitMonster(mons)
 			}
@@ -93,24 +197,24 @@ func (g *game) AttackMonster(mons *monster) {
 		g.HitMonster(mons)
 		deltaX := mons.Pos.X - g.Player.Pos.X
 		deltaY := mons.Pos.Y - g.Player.Pos.Y
-		behind := position{g.Player.Pos.X + 2*deltaX, g.Player.Pos.Y + 2*deltaY}
+		behind := Position{g.Player.Pos.X + 2*deltaX, g.Player.Pos.Y + 2*deltaY}
 		if g.Dungeon.Valid(behind) {
-			mons, _ := g.MonsterAt(behind)
+			mons := g.MonsterAt(behind)
 			if mons.Exists() {
 				g.HitMonster(mons)
 			}
 		}
 	default:
 		g.HitMonster(mons)
-		if (g.Player.Weapon == Sword || g.Player.Weapon == DoubleSword) && RandInt(4) == 0 {
+		if (g.Player.Weapon == Sword || g.Player.Weapon == DoubleSword) && g.Rand.Int(4) == 0 {
 			g.HitMonster(mons)
 		}
 	}
 }
 
-func (g *game) HitMonster(mons *monster) {
-	acc := RandInt(g.Player.Accuracy())
-	ev := RandInt(mons.Evasion)
+func (g *Game) HitMonster(mons *Monster) {
+	acc := g.Rand.Int(g.Player.Accuracy())
+	ev := g.Rand.Int(mons.Evasion)
 	if mons.State == Resting {
 		ev /= 2 + 1
 	}
@@ -118,7 +222,7 @@ func (g *game) HitMonster(mons *monster) {
 		g.MakeNoise(12, mons.Pos)
 		bonus := 0
 		if g.Player.HasStatus(StatusBerserk) {
-			bonus += RandInt(5)
+			bonus += g.Rand.Int(5)
 		}
 		attack := g.HitDamage(g.Player.Attack()+bonus, mons.Armor)
 		if mons.State == Resting {
@@ -0,0 +1,186 @@
+package boohu
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// dailyLayout is the layout of the dates of daily challenges.
+const dailyLayout = "2006-01-02"
+
+// DailyDate returns the date of the daily challenge played at t. Days
+// change at midnight UTC, so that every player has the same challenge.
+func DailyDate(t time.Time) string {
+	return t.UTC().Format(dailyLayout)
+}
+
+// DailySeed returns the seed of the daily challenge played at t. It is a hash
+// of the date, so that the challenges of the next days cannot be guessed as
+// easily as a number made of the date.
+func DailySeed(t time.Time) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("boohu daily challenge " + DailyDate(t)))
+	return int64(h.Sum64())
+}
+
+// DailyAttempt records that a character started a daily challenge.
+type DailyAttempt struct {
+	Date string
+	Name string
+}
+
+// DailyAttemptError is returned by StartDaily when the daily challenge was
+// already played, by the character Name.
+type DailyAttemptError struct {
+	Date string
+	Name string
+}
+
+func (e *DailyAttemptError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("the daily challenge of %s was already played", e.Date)
+	}
+	return fmt.Sprintf("%s already played the daily challenge of %s", e.Name, e.Date)
+}
+
+func (g *Game) dailyFile(name string) (string, error) {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, name), nil
+}
+
+// StartDaily prepares the daily challenge played at t for the character.
+// The player gets only one attempt per day, whatever the character: the
+// attempt is recorded in the data directory as soon as the game starts, so
+// that abandoning it or creating a new character does not allow a new one.
+func (g *Game) StartDaily(t time.Time) error {
+	file, err := g.dailyFile("daily-attempts.gob")
+	if err != nil {
+		return err
+	}
+	return g.startDaily(file, t)
+}
+
+func (g *Game) startDaily(file string, t time.Time) error {
+	date := DailyDate(t)
+	attempts := []DailyAttempt{}
+	err := readGob(file, &attempts)
+	if err != nil {
+		return err
+	}
+	for _, a := range attempts {
+		if a.Date == date {
+			return &DailyAttemptError{Date: date, Name: a.Name}
+		}
+	}
+	// older attempts are not needed anymore
+	err = writeGob(file, []DailyAttempt{{Date: date, Name: g.Name}})
+	if err != nil {
+		return err
+	}
+	g.Daily = date
+	g.SetSeed(DailySeed(t))
+	return nil
+}
+
+// DailyScores returns the leaderboard of the daily challenge of date, best
+// first.
+func (g *Game) DailyScores(date string) ([]HighScore, error) {
+	file, err := g.dailyFile("daily-scores.gob")
+	if err != nil {
+		return nil, err
+	}
+	scores := []HighScore{}
+	err = readGob(file, &scores)
+	if err != nil {
+		return nil, err
+	}
+	return dailyScores(scores, date), nil
+}
+
+func dailyScores(scores []HighScore, date string) []HighScore {
+	daily := []HighScore{}
+	for _, hs := range scores {
+		if hs.Daily == date {
+			daily = append(daily, hs)
+		}
+	}
+	return daily
+}
+
+// WriteDailyScore adds a finished daily challenge to the daily leaderboard,
+// and returns its rank among the games of the same day.
+func (g *Game) WriteDailyScore() (int, error) {
+	if g.noSave || g.Wizard || g.Daily == "" {
+		return 0, nil
+	}
+	file, err := g.dailyFile("daily-scores.gob")
+	if err != nil {
+		return 0, err
+	}
+	return addDailyScore(file, g.HighScore())
+}
+
+// addDailyScore adds hs to the daily leaderboard of file, and returns its
+// rank. Games are compared by score, then by depth, then by turns, fewer
+// being better.
+func addDailyScore(file string, hs HighScore) (int, error) {
+	scores := []HighScore{}
+	err := readGob(file, &scores)
+	if err != nil {
+		return 0, err
+	}
+	scores = append(scores, hs)
+	sort.SliceStable(scores, func(i, j int) bool {
+		a, b := scores[i], scores[j]
+		switch {
+		case a.Score != b.Score:
+			return a.Score > b.Score
+		case a.Depth != b.Depth:
+			return a.Depth > b.Depth
+		default:
+			return a.Turns < b.Turns
+		}
+	})
+	err = writeGob(file, scores)
+	if err != nil {
+		return 0, err
+	}
+	for i, s := range dailyScores(scores, hs.Daily) {
+		if s == hs {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
+
+// readGob decodes file into v, which is left unchanged if the file does not
+// exist.
+func readGob(file string, v interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func writeGob(file string, v interface{}) error {
+	var data bytes.Buffer
+	err := gob.NewEncoder(&data).Encode(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data.Bytes(), 0644)
+}
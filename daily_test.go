@@ -0,0 +1,69 @@
+package boohu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDaily(t *testing.T) {
+	file := t.TempDir() + "/daily-attempts.gob"
+	day := time.Date(2018, 1, 2, 23, 0, 0, 0, time.UTC)
+	g, _ := newHeadlessGame(13, "")
+	g.Name = "Alice"
+	if err := g.startDaily(file, day); err != nil {
+		t.Fatal(err)
+	}
+	if g.Daily != "2018-01-02" || g.Seed != DailySeed(day) || g.Seed == 20180102 {
+		t.Errorf("Bad daily challenge: %s with seed %d", g.Daily, g.Seed)
+	}
+	if _, ok := g.startDaily(file, day.Add(30*time.Minute)).(*DailyAttemptError); !ok {
+		t.Errorf("Second attempt of the same day accepted")
+	}
+	g.Name = "Bob"
+	if _, ok := g.startDaily(file, day.Add(40*time.Minute)).(*DailyAttemptError); !ok {
+		t.Errorf("Second attempt of the same day with another character accepted")
+	}
+	if err := g.startDaily(file, day.Add(2*time.Hour)); err != nil || g.Daily != "2018-01-03" {
+		t.Errorf("New day refused: %v", err)
+	}
+	if DailySeed(day) == DailySeed(day.Add(2*time.Hour)) {
+		t.Errorf("Same seed for two days")
+	}
+	attempts := []DailyAttempt{}
+	if err := readGob(file, &attempts); err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 1 || attempts[0].Date != "2018-01-03" || attempts[0].Name != "Bob" {
+		t.Errorf("Bad daily attempts: %+v", attempts)
+	}
+}
+
+func TestDailyScores(t *testing.T) {
+	file := t.TempDir() + "/daily-scores.gob"
+	scores := []HighScore{
+		{Name: "a", Score: 100, Depth: 2, Turns: 500, Daily: "2018-01-02"},
+		{Name: "b", Score: 100, Depth: 2, Turns: 400, Daily: "2018-01-02"},
+		{Name: "c", Score: 300, Depth: 3, Daily: "2018-01-01"},
+		{Name: "d", Score: 100, Depth: 3, Turns: 900, Daily: "2018-01-02"},
+	}
+	for i, want := range []int{1, 1, 1, 1} {
+		rank, err := addDailyScore(file, scores[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rank != want {
+			t.Errorf("Rank of %s: %d instead of %d", scores[i].Name, rank, want)
+		}
+	}
+	all := []HighScore{}
+	if err := readGob(file, &all); err != nil {
+		t.Fatal(err)
+	}
+	names := ""
+	for _, hs := range dailyScores(all, "2018-01-02") {
+		names += hs.Name
+	}
+	if names != "dba" {
+		t.Errorf("Bad daily leaderboard: %s", names)
+	}
+}
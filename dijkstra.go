@@ -1,43 +1,13 @@
-package main
+package boohu
 
-import (
-	"bytes"
-	"container/heap"
-	"fmt"
-)
+import "container/heap"
 
 type Dijkstrer interface {
-	Neighbors(position) []position
-	Cost(position, position) int
+	Neighbors(Position) []Position
+	Cost(Position, Position) int
 }
 
-func (g *game) drawDijkstra(nm nodeMap) string {
-	b := &bytes.Buffer{}
-	for y := 0; y < g.Dungeon.Heigth; y++ {
-		for x := 0; x < g.Dungeon.Width; x++ {
-			pos := position{x, y}
-			n, ok := nm[pos]
-			if ok {
-				if pos == g.Player.Pos {
-					fmt.Fprintf(b, "%d@", n.Cost)
-				} else {
-					c := g.Dungeon.Cell(pos)
-					if c.T == WallCell {
-						fmt.Fprintf(b, "%d#", n.Cost)
-					} else {
-						fmt.Fprintf(b, "%d ", n.Cost)
-					}
-				}
-			} else {
-				fmt.Fprintf(b, "# ")
-			}
-		}
-		fmt.Fprintf(b, "\n")
-	}
-	return b.String()
-}
-
-func Dijkstra(dij Dijkstrer, sources []position, maxCost int) nodeMap {
+func Dijkstra(dij Dijkstrer, sources []Position, maxCost int) nodeMap {
 	nm := nodeMap{}
 	nq := &priorityQueue{}
 	heap.Init(nq)
@@ -1,13 +1,13 @@
-package main
+package boohu
 
 import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
 type rodSlice []rod
@@ -16,11 +16,17 @@ func (rs rodSlice) Len() int           { return len(rs) }
 func (rs rodSlice) Swap(i, j int)      { rs[i], rs[j] = rs[j], rs[i] }
 func (rs rodSlice) Less(i, j int) bool { return int(rs[i]) < int(rs[j]) }
 
-type consumableSlice []consumable
+type potionSlice []potion
 
-func (cs consumableSlice) Len() int           { return len(cs) }
-func (cs consumableSlice) Swap(i, j int)      { cs[i], cs[j] = cs[j], cs[i] }
-func (cs consumableSlice) Less(i, j int) bool { return cs[i].Int() < cs[j].Int() }
+func (ps potionSlice) Len() int           { return len(ps) }
+func (ps potionSlice) Swap(i, j int)      { ps[i], ps[j] = ps[j], ps[i] }
+func (ps potionSlice) Less(i, j int) bool { return int(ps[i]) < int(ps[j]) }
+
+type projectileSlice []projectile
+
+func (ps projectileSlice) Len() int           { return len(ps) }
+func (ps projectileSlice) Swap(i, j int)      { ps[i], ps[j] = ps[j], ps[i] }
+func (ps projectileSlice) Less(i, j int) bool { return int(ps[i]) < int(ps[j]) }
 
 type statusSlice []status
 
@@ -36,7 +42,7 @@ func (ms monsSlice) Less(i, j int) bool {
 	return ms[i].Dangerousness() > ms[j].Dangerousness()
 }
 
-func (g *game) KillStats(mons *monster) {
+func (g *Game) KillStats(mons *Monster) {
 	g.Killed++
 	if g.KilledMons == nil {
 		g.KilledMons = map[monsterKind]int{}
@@ -47,7 +53,7 @@ func (g *game) KillStats(mons *monster) {
 	}
 }
 
-func (g *game) DumpAptitudes() string {
+func (g *Game) DumpAptitudes() string {
 	apts := []string{}
 	for apt, b := range g.Player.Aptitudes {
 		if b {
@@ -61,7 +67,18 @@ func (g *game) DumpAptitudes() string {
 	return "Aptitudes:\n" + strings.Join(apts, "\n")
 }
 
-func (g *game) DumpStatuses() string {
+func (g *Game) SortedStatuses() statusSlice {
+	sts := statusSlice{}
+	for st, c := range g.Player.Statuses {
+		if c > 0 {
+			sts = append(sts, st)
+		}
+	}
+	sort.Sort(sts)
+	return sts
+}
+
+func (g *Game) DumpStatuses() string {
 	sts := sort.StringSlice{}
 	for st, c := range g.Player.Statuses {
 		if c > 0 {
@@ -75,7 +92,7 @@ func (g *game) DumpStatuses() string {
 	return "Statuses:\n" + strings.Join(sts, "\n")
 }
 
-func (g *game) SortedRods() rodSlice {
+func (g *Game) SortedRods() rodSlice {
 	var rs rodSlice
 	for k, p := range g.Player.Rods {
 		if p == nil {
@@ -87,7 +104,7 @@ func (g *game) SortedRods() rodSlice {
 	return rs
 }
 
-func (g *game) SortedKilledMonsters() monsSlice {
+func (g *Game) SortedKilledMonsters() monsSlice {
 	var ms monsSlice
 	for mk, p := range g.KilledMons {
 		if p == 0 {
@@ -99,39 +116,49 @@ func (g *game) SortedKilledMonsters() monsSlice {
 	return ms
 }
 
-func (g *game) SortedPotions() consumableSlice {
-	var cs consumableSlice
+func (g *Game) SortedPotions() potionSlice {
+	var ps potionSlice
 	for k := range g.Player.Consumables {
 		switch k := k.(type) {
 		case potion:
-			cs = append(cs, k)
+			ps = append(ps, k)
 		}
 	}
-	sort.Sort(cs)
-	return cs
+	sort.Sort(ps)
+	return ps
 }
 
-func (g *game) SortedProjectiles() consumableSlice {
-	var cs consumableSlice
+func (g *Game) SortedProjectiles() projectileSlice {
+	var ps projectileSlice
 	for k := range g.Player.Consumables {
 		switch k := k.(type) {
 		case projectile:
-			cs = append(cs, k)
+			ps = append(ps, k)
 		}
 	}
-	sort.Sort(cs)
-	return cs
+	sort.Sort(ps)
+	return ps
 }
 
-func (g *game) Dump() string {
+func (g *Game) Dump() string {
 	buf := &bytes.Buffer{}
+	if g.Name != "" {
+		fmt.Fprintf(buf, "Character: %s\n", g.Name)
+	}
+	fmt.Fprintf(buf, "Dumped on %s by boohu %s.\n\n", time.Now().Format("2006-01-02 15:04"), Version)
 	if g.Wizard {
 		fmt.Fprintf(buf, "**WIZARD MODE**\n")
 	}
-	if g.Player.HP > 0 && g.Depth > 12 {
+	if g.Daily != "" {
+		fmt.Fprintf(buf, "Daily challenge of %s.\n", g.Daily)
+	}
+	if g.Won() {
 		fmt.Fprintf(buf, "You escaped from Hareka's Underground alive!\n")
 	} else if g.Player.HP <= 0 {
 		fmt.Fprintf(buf, "You died while exploring depth %d of Hareka's Underground.\n", g.Depth)
+		buf.WriteString(g.DumpKilledBy())
+	} else if g.Abandoned {
+		fmt.Fprintf(buf, "You abandoned the game while exploring depth %d of Hareka's Underground.\n", g.Depth)
 	} else {
 		fmt.Fprintf(buf, "You are exploring depth %d of Hareka's Underground.\n", g.Depth)
 	}
@@ -174,10 +201,10 @@ func (g *game) Dump() string {
 		fmt.Fprintf(buf, "You do not have any potions.\n")
 	}
 	fmt.Fprintf(buf, "\n")
-	ps = g.SortedProjectiles()
-	if len(ps) > 0 {
+	js := g.SortedProjectiles()
+	if len(js) > 0 {
 		fmt.Fprintf(buf, "Projectiles:\n")
-		for _, c := range ps {
+		for _, c := range js {
 			fmt.Fprintf(buf, "- %s (%d available)\n", c, g.Player.Consumables[c])
 		}
 	} else {
@@ -188,7 +215,19 @@ func (g *game) Dump() string {
 	fmt.Fprintf(buf, "You collected %d gold coins.\n", g.Player.Gold)
 	fmt.Fprintf(buf, "You killed %d monsters.\n", g.Killed)
 	fmt.Fprintf(buf, "You spent %.1f turns in the Underground.\n", float64(g.Turn)/10)
+	fmt.Fprintf(buf, "Your score is %d.\n", g.Score())
+	fmt.Fprintf(buf, "The game was played with seed %d.\n", g.Seed)
+	if g.Recoveries > 0 {
+		fmt.Fprintf(buf, "The game was recovered %d times after not ending properly.\n", g.Recoveries)
+	}
 	fmt.Fprintf(buf, "\n")
+	if len(g.Damages) > 0 {
+		fmt.Fprintf(buf, "Last damage taken:\n")
+		for _, d := range g.Damages {
+			fmt.Fprintf(buf, "%s\n", d)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
 	fmt.Fprintf(buf, "Last messages:\n")
 	for i := len(g.Log) - 10; i < len(g.Log); i++ {
 		if i >= 0 {
@@ -208,11 +247,20 @@ func (g *game) Dump() string {
 	return buf.String()
 }
 
-func (g *game) DumpStory() string {
+// DumpKilledBy describes the deadly hit, if it was recorded.
+func (g *Game) DumpKilledBy() string {
+	d := g.KilledBy
+	if d.Attack == NoAttack {
+		return ""
+	}
+	return fmt.Sprintf("You were killed by %s, which dealt %d damage when you had %d HP.\n", d.Cause(), d.Damage, d.HP)
+}
+
+func (g *Game) DumpStory() string {
 	return strings.Join(g.Story, "\n")
 }
 
-func (g *game) DumpDungeon() string {
+func (g *Game) DumpDungeon() string {
 	buf := bytes.Buffer{}
 	for i, c := range g.Dungeon.Cells {
 		if i%g.Dungeon.Width == 0 {
@@ -254,7 +302,7 @@ func (g *game) DumpDungeon() string {
 				} else if _, ok := g.Gold[pos]; ok {
 					r = '$'
 				}
-				m, _ := g.MonsterAt(pos)
+				m := g.MonsterAt(pos)
 				if m.Exists() && (g.Player.LOS[m.Pos] || g.Wizard) {
 					r = m.Kind.Letter()
 				}
@@ -268,7 +316,7 @@ func (g *game) DumpDungeon() string {
 	return buf.String()
 }
 
-func (g *game) DumpedKilledMonsters() string {
+func (g *Game) DumpedKilledMonsters() string {
 	buf := &bytes.Buffer{}
 	fmt.Fprint(buf, "Killed Monsters:\n")
 	ms := g.SortedKilledMonsters()
@@ -278,38 +326,66 @@ func (g *game) DumpedKilledMonsters() string {
 	return buf.String()
 }
 
-func (g *game) SimplifedDump() string {
+func (g *Game) SimplifedDump() string {
 	buf := &bytes.Buffer{}
 	if g.Wizard {
 		fmt.Fprintf(buf, "**WIZARD MODE**\n")
 	}
-	if g.Player.HP > 0 && g.Depth > 12 {
+	if g.Daily != "" {
+		fmt.Fprintf(buf, "Daily challenge of %s.\n", g.Daily)
+	}
+	if g.Won() {
 		fmt.Fprintf(buf, "You escaped from Hareka's Underground alive!\n")
 	} else if g.Player.HP <= 0 {
 		fmt.Fprintf(buf, "You died while exploring depth %d of Hareka's Underground.\n", g.Depth)
+		buf.WriteString(g.DumpKilledBy())
+	} else if g.Abandoned {
+		fmt.Fprintf(buf, "You abandoned the game while exploring depth %d of Hareka's Underground.\n", g.Depth)
 	} else {
 		fmt.Fprintf(buf, "You are exploring depth %d of Hareka's Underground.\n", g.Depth)
 	}
 	fmt.Fprintf(buf, "You collected %d gold coins.\n", g.Player.Gold)
 	fmt.Fprintf(buf, "You killed %d monsters.\n", g.Killed)
 	fmt.Fprintf(buf, "You spent %.1f turns in the Underground.\n", float64(g.Turn)/10)
+	fmt.Fprintf(buf, "Your score is %d.\n", g.Score())
 	fmt.Fprintf(buf, "\n")
-	dataDir, err := g.DataDir()
-	if err == nil {
-		fmt.Fprintf(buf, "Full dump written to %s.\n", filepath.Join(dataDir, "dump"))
+	if g.morgueFile != "" {
+		fmt.Fprintf(buf, "Full dump written to %s.\n", g.morgueFile)
 	}
 	fmt.Fprintf(buf, "\n\n")
 	fmt.Fprintf(buf, "───Press esc or space to quit───")
 	return buf.String()
 }
 
-func (g *game) WriteDump() error {
-	dataDir, err := g.DataDir()
+// DumpFile returns the path of the scratch character dump file, overwritten
+// by every dump written during a game. Dumps of finished games are archived
+// by WriteMorgue instead.
+func (g *Game) DumpFile() (string, error) {
+	return g.characterFile("dump", "")
+}
+
+// WriteDump writes the text dump to DumpFile, and the JSON dump next to it.
+func (g *Game) WriteDump() error {
+	if g.noSave {
+		return nil
+	}
+	dumpFile, err := g.DumpFile()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing dump: %s", err)
 		return err
 	}
-	err = ioutil.WriteFile(filepath.Join(dataDir, "dump"), []byte(g.Dump()), 0644)
+	return g.writeDumpFiles(dumpFile, dumpFile+".json")
+}
+
+func (g *Game) writeDumpFiles(file, jsonFile string) error {
+	err := ioutil.WriteFile(file, []byte(g.Dump()), 0644)
+	if err == nil {
+		var data []byte
+		data, err = g.MarshalJSONDump()
+		if err == nil {
+			err = ioutil.WriteFile(jsonFile, data, 0644)
+		}
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error writing dump: %s", err)
 		return err
@@ -1,6 +1,6 @@
 // many ideas here from articles found at http://www.roguebasin.com/
 
-package main
+package boohu
 
 import (
 	"sort"
@@ -25,24 +25,24 @@ const (
 )
 
 type room struct {
-	pos position
+	pos Position
 	w   int
 	h   int
 }
 
-func (d *dungeon) Cell(pos position) cell {
+func (d *dungeon) Cell(pos Position) cell {
 	return d.Cells[pos.Y*d.Width+pos.X]
 }
 
-func (d *dungeon) Valid(pos position) bool {
+func (d *dungeon) Valid(pos Position) bool {
 	return pos.X < d.Width && pos.Y < d.Heigth && pos.X >= 0 && pos.Y >= 0
 }
 
-func (d *dungeon) SetCell(pos position, t terrain) {
+func (d *dungeon) SetCell(pos Position, t terrain) {
 	d.Cells[pos.Y*d.Width+pos.X].T = t
 }
 
-func (d *dungeon) SetExplored(pos position) {
+func (d *dungeon) SetExplored(pos Position) {
 	d.Cells[pos.Y*d.Width+pos.X].Explored = true
 }
 
@@ -50,13 +50,13 @@ func roomDistance(r1, r2 room) int {
 	return Abs(r1.pos.X-r2.pos.X) + Abs(r1.pos.Y-r2.pos.Y)
 }
 
-func nearRoom(rooms []room, r room) room {
+func nearRoom(rnd *rng, rooms []room, r room) room {
 	closest := rooms[0]
 	d := roomDistance(r, closest)
 	for _, nextRoom := range rooms {
 		nd := roomDistance(r, nextRoom)
 		if nd < d {
-			n := RandInt(10)
+			n := rnd.Int(10)
 			if n > 3 {
 				d = nd
 				closest = nextRoom
@@ -66,13 +66,13 @@ func nearRoom(rooms []room, r room) room {
 	return closest
 }
 
-func nearestRoom(rooms []room, r room) room {
+func nearestRoom(rnd *rng, rooms []room, r room) room {
 	closest := rooms[0]
 	d := roomDistance(r, closest)
 	for _, nextRoom := range rooms {
 		nd := roomDistance(r, nextRoom)
 		if nd < d {
-			n := RandInt(10)
+			n := rnd.Int(10)
 			if n > 0 {
 				d = nd
 				closest = nextRoom
@@ -103,22 +103,22 @@ func (d *dungeon) connectRooms(r1, r2 room) {
 		}
 		if x < r2.pos.X {
 			x++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if x > r2.pos.X {
 			x--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if y < r2.pos.Y {
 			y++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if y > r2.pos.Y {
 			y--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		break
@@ -136,59 +136,59 @@ func (d *dungeon) connectRoomsDiagonally(r1, r2 room) {
 		}
 		if x < r2.pos.X && y < r2.pos.Y {
 			x++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			y++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if x > r2.pos.X && y < r2.pos.Y {
 			x--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			y++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if x > r2.pos.X && y > r2.pos.Y {
 			x--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			y--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if x < r2.pos.X && y > r2.pos.Y {
 			x++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			y--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if x < r2.pos.X {
 			x++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if x > r2.pos.X {
 			x--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if y < r2.pos.Y {
 			y++
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		if y > r2.pos.Y {
 			y--
-			d.SetCell(position{x, y}, FreeCell)
+			d.SetCell(Position{x, y}, FreeCell)
 			continue
 		}
 		break
 	}
 }
 
-func (d *dungeon) Neighbors(pos position) []position {
-	neighbors := [8]position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
-	validNeighbors := []position{}
+func (d *dungeon) Neighbors(pos Position) []Position {
+	neighbors := [8]Position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
+	validNeighbors := []Position{}
 	for _, npos := range neighbors {
 		if d.Valid(npos) {
 			validNeighbors = append(validNeighbors, npos)
@@ -197,9 +197,9 @@ func (d *dungeon) Neighbors(pos position) []position {
 	return validNeighbors
 }
 
-func (d *dungeon) CardinalNeighbors(pos position) []position {
-	neighbors := [4]position{pos.E(), pos.W(), pos.N(), pos.S()}
-	validNeighbors := []position{}
+func (d *dungeon) CardinalNeighbors(pos Position) []Position {
+	neighbors := [4]Position{pos.E(), pos.W(), pos.N(), pos.S()}
+	validNeighbors := []Position{}
 	for _, npos := range neighbors {
 		if d.Valid(npos) {
 			validNeighbors = append(validNeighbors, npos)
@@ -208,11 +208,11 @@ func (d *dungeon) CardinalNeighbors(pos position) []position {
 	return validNeighbors
 }
 
-func (d *dungeon) Area(pos position, radius int) []position {
-	area := []position{}
+func (d *dungeon) Area(pos Position, radius int) []Position {
+	area := []Position{}
 	for x := pos.X - radius; x <= pos.X+radius; x++ {
 		for y := pos.Y - radius; y <= pos.Y+radius; y++ {
-			pos := position{x, y}
+			pos := Position{x, y}
 			if d.Valid(pos) {
 				area = append(area, pos)
 			}
@@ -225,18 +225,18 @@ type dungeonPath struct {
 	dungeon *dungeon
 }
 
-func (dp *dungeonPath) Neighbors(pos position) []position {
+func (dp *dungeonPath) Neighbors(pos Position) []Position {
 	return dp.dungeon.Neighbors(pos)
 }
 
-func (dp *dungeonPath) Cost(from, to position) int {
+func (dp *dungeonPath) Cost(from, to Position) int {
 	if dp.dungeon.Cell(to).T == WallCell {
 		return 4
 	}
 	return 1
 }
 
-func (dp *dungeonPath) Estimation(from, to position) int {
+func (dp *dungeonPath) Estimation(from, to Position) int {
 	return from.Distance(to)
 }
 
@@ -251,18 +251,18 @@ func (d *dungeon) ConnectRoomsShortestPath(r1, r2 room) {
 func (d *dungeon) PutRoom(r room) {
 	for i := r.pos.X; i < r.pos.X+r.w; i++ {
 		for j := r.pos.Y; j < r.pos.Y+r.h; j++ {
-			if d.Valid(position{i, j}) {
-				d.SetCell(position{i, j}, FreeCell)
+			if d.Valid(Position{i, j}) {
+				d.SetCell(Position{i, j}, FreeCell)
 			}
 		}
 	}
 }
 
-func (d *dungeon) CellPosition(i int) position {
-	return position{i - (i/d.Width)*d.Width, i / d.Width}
+func (d *dungeon) CellPosition(i int) Position {
+	return Position{i - (i/d.Width)*d.Width, i / d.Width}
 }
 
-func (g *game) GenRuinsMap(h, w int) {
+func (g *Game) GenRuinsMap(h, w int) {
 	d := &dungeon{}
 	d.Cells = make([]cell, h*w)
 	d.Width = w
@@ -278,9 +278,9 @@ func (g *game) GenRuinsMap(h, w int) {
 		for count > 0 {
 			count--
 			ro = room{
-				pos: position{RandInt(w - 1), RandInt(h - 1)},
-				w:   3 + RandInt(5),
-				h:   2 + RandInt(3)}
+				pos: Position{g.Rand.Int(w - 1), g.Rand.Int(h - 1)},
+				w:   3 + g.Rand.Int(5),
+				h:   2 + g.Rand.Int(3)}
 			if !noIntersect {
 				break
 			}
@@ -291,13 +291,13 @@ func (g *game) GenRuinsMap(h, w int) {
 
 		d.PutRoom(ro)
 		if len(rooms) > 0 {
-			r := RandInt(100)
+			r := g.Rand.Int(100)
 			if r > 75 {
-				d.connectRooms(nearRoom(rooms, ro), ro)
+				d.connectRooms(nearRoom(&g.Rand, rooms, ro), ro)
 			} else if r > 25 {
-				d.ConnectRoomsShortestPath(nearRoom(rooms, ro), ro)
+				d.ConnectRoomsShortestPath(nearRoom(&g.Rand, rooms, ro), ro)
 			} else {
-				d.connectRoomsDiagonally(nearRoom(rooms, ro), ro)
+				d.connectRoomsDiagonally(nearRoom(&g.Rand, rooms, ro), ro)
 			}
 		}
 		rooms = append(rooms, ro)
@@ -313,7 +313,7 @@ func (rs roomSlice) Less(i, j int) bool {
 	return rs[i].pos.Y < rs[j].pos.Y || rs[i].pos.Y == rs[j].pos.Y && rs[i].pos.X < rs[j].pos.X
 }
 
-func (g *game) GenRoomMap(h, w int) {
+func (g *Game) GenRoomMap(h, w int) {
 	d := &dungeon{}
 	d.Cells = make([]cell, h*w)
 	d.Width = w
@@ -326,9 +326,9 @@ func (g *game) GenRoomMap(h, w int) {
 		for count > 0 {
 			count--
 			ro = room{
-				pos: position{RandInt(w - 1), RandInt(h - 1)},
-				w:   5 + RandInt(4),
-				h:   3 + RandInt(3)}
+				pos: Position{g.Rand.Int(w - 1), g.Rand.Int(h - 1)},
+				w:   5 + g.Rand.Int(4),
+				h:   3 + g.Rand.Int(3)}
 			if !noIntersect {
 				break
 			}
@@ -345,28 +345,28 @@ func (g *game) GenRoomMap(h, w int) {
 		if i == 0 {
 			continue
 		}
-		r := RandInt(100)
+		r := g.Rand.Int(100)
 		if r > 50 {
-			d.connectRooms(nearestRoom(rooms[:i], ro), ro)
+			d.connectRooms(nearestRoom(&g.Rand, rooms[:i], ro), ro)
 		} else if r > 25 {
-			d.ConnectRoomsShortestPath(nearRoom(rooms[:i], ro), ro)
+			d.ConnectRoomsShortestPath(nearRoom(&g.Rand, rooms[:i], ro), ro)
 		} else {
-			d.connectRoomsDiagonally(nearestRoom(rooms[:i], ro), ro)
+			d.connectRoomsDiagonally(nearestRoom(&g.Rand, rooms[:i], ro), ro)
 		}
 	}
 	g.Dungeon = d
 }
 
-func (d *dungeon) FreeCell() position {
+func (d *dungeon) FreeCell(rnd *rng) Position {
 	count := 0
 	for {
 		count++
 		if count > 1000 {
 			panic("FreeCell")
 		}
-		x := RandInt(d.Width)
-		y := RandInt(d.Heigth)
-		pos := position{x, y}
+		x := rnd.Int(d.Width)
+		y := rnd.Int(d.Heigth)
+		pos := Position{x, y}
 		c := d.Cell(pos)
 		if c.T == FreeCell {
 			return pos
@@ -374,16 +374,16 @@ func (d *dungeon) FreeCell() position {
 	}
 }
 
-func (d *dungeon) WallCell() position {
+func (d *dungeon) WallCell(rnd *rng) Position {
 	count := 0
 	for {
 		count++
 		if count > 1000 {
 			panic("WallCell")
 		}
-		x := RandInt(d.Width)
-		y := RandInt(d.Heigth)
-		pos := position{x, y}
+		x := rnd.Int(d.Width)
+		y := rnd.Int(d.Heigth)
+		pos := Position{x, y}
 		c := d.Cell(pos)
 		if c.T == WallCell {
 			return pos
@@ -391,20 +391,20 @@ func (d *dungeon) WallCell() position {
 	}
 }
 
-func (g *game) GenCaveMap(h, w int) {
+func (g *Game) GenCaveMap(h, w int) {
 	d := &dungeon{}
 	d.Cells = make([]cell, h*w)
 	d.Width = w
 	d.Heigth = h
-	pos := position{40, 10}
+	pos := Position{40, 10}
 	max := 21 * 42
 	d.SetCell(pos, FreeCell)
 	cells := 1
 	notValid := 0
 	lastValid := pos
-	diag := RandInt(4) == 0
+	diag := g.Rand.Int(4) == 0
 	for cells < max {
-		npos := pos.RandomNeighbor(diag)
+		npos := pos.RandomNeighbor(&g.Rand, diag)
 		if !d.Valid(pos) && d.Valid(npos) && d.Cell(npos).T == WallCell {
 			pos = lastValid
 			continue
@@ -437,8 +437,8 @@ loop:
 		if i > 1000 {
 			break
 		}
-		diag = RandInt(2) == 0
-		block := d.DigBlock(diag)
+		diag = g.Rand.Int(2) == 0
+		block := d.DigBlock(&g.Rand, diag)
 		if len(block) == 0 {
 			continue loop
 		}
@@ -454,7 +454,7 @@ loop:
 	g.Dungeon = d
 }
 
-func (d *dungeon) HasFreeNeighbor(pos position) bool {
+func (d *dungeon) HasFreeNeighbor(pos Position) bool {
 	neighbors := d.Neighbors(pos)
 	for _, pos := range neighbors {
 		if d.Cell(pos).T == FreeCell {
@@ -464,7 +464,7 @@ func (d *dungeon) HasFreeNeighbor(pos position) bool {
 	return false
 }
 
-func (d *dungeon) HasFreeExploredNeighbor(pos position) bool {
+func (d *dungeon) HasFreeExploredNeighbor(pos Position) bool {
 	neighbors := d.Neighbors(pos)
 	for _, pos := range neighbors {
 		c := d.Cell(pos)
@@ -475,18 +475,18 @@ func (d *dungeon) HasFreeExploredNeighbor(pos position) bool {
 	return false
 }
 
-func (d *dungeon) DigBlock(diag bool) []position {
-	pos := d.WallCell()
-	block := []position{}
+func (d *dungeon) DigBlock(rnd *rng, diag bool) []Position {
+	pos := d.WallCell(rnd)
+	block := []Position{}
 	for {
 		block = append(block, pos)
 		if d.HasFreeNeighbor(pos) {
 			break
 		}
-		pos = pos.RandomNeighbor(diag)
+		pos = pos.RandomNeighbor(rnd, diag)
 		if !d.Valid(pos) {
 			block = block[:0]
-			pos = d.WallCell()
+			pos = d.WallCell(rnd)
 			continue
 		}
 		if !d.Valid(pos) {
@@ -496,12 +496,12 @@ func (d *dungeon) DigBlock(diag bool) []position {
 	return block
 }
 
-func (g *game) GenCaveMapTree(h, w int) {
+func (g *Game) GenCaveMapTree(h, w int) {
 	d := &dungeon{}
 	d.Cells = make([]cell, h*w)
 	d.Width = w
 	d.Heigth = h
-	center := position{40, 10}
+	center := Position{40, 10}
 	d.SetCell(center, FreeCell)
 	d.SetCell(center.E(), FreeCell)
 	d.SetCell(center.NE(), FreeCell)
@@ -513,10 +513,10 @@ func (g *game) GenCaveMapTree(h, w int) {
 	d.SetCell(center.SW(), FreeCell)
 	max := 21 * 23
 	cells := 1
-	diag := RandInt(2) == 0
+	diag := g.Rand.Int(2) == 0
 loop:
 	for cells < max {
-		block := d.DigBlock(diag)
+		block := d.DigBlock(&g.Rand, diag)
 		if len(block) == 0 {
 			continue loop
 		}
@@ -530,7 +530,7 @@ loop:
 	g.Dungeon = d
 }
 
-func (d *dungeon) WallNeighborsCount(pos position) int {
+func (d *dungeon) WallNeighborsCount(pos Position) int {
 	neighbors := d.Neighbors(pos)
 	count := 0
 	for _, npos := range neighbors {
@@ -541,7 +541,7 @@ func (d *dungeon) WallNeighborsCount(pos position) int {
 	return count
 }
 
-func (d *dungeon) WallAreaCount(pos position, radius int) int {
+func (d *dungeon) WallAreaCount(pos Position, radius int) int {
 	neighbors := d.Area(pos, radius)
 	count := 0
 	for _, npos := range neighbors {
@@ -558,9 +558,9 @@ func (d *dungeon) WallAreaCount(pos position, radius int) int {
 	return count
 }
 
-func (d *dungeon) Connected(pos position) (map[position]bool, int) {
-	conn := map[position]bool{}
-	stack := []position{pos}
+func (d *dungeon) Connected(pos Position) (map[Position]bool, int) {
+	conn := map[Position]bool{}
+	stack := []Position{pos}
 	count := 0
 	conn[pos] = true
 	for len(stack) > 0 {
@@ -578,8 +578,8 @@ func (d *dungeon) Connected(pos position) (map[position]bool, int) {
 	return conn, count
 }
 
-func (d *dungeon) connex() bool {
-	pos := d.FreeCell()
+func (d *dungeon) connex(rnd *rng) bool {
+	pos := d.FreeCell(rnd)
 	conn, _ := d.Connected(pos)
 	for i, c := range d.Cells {
 		if c.T == FreeCell && !conn[d.CellPosition(i)] {
@@ -589,13 +589,13 @@ func (d *dungeon) connex() bool {
 	return true
 }
 
-func (g *game) RunCellularAutomataCave(h, w int) bool {
+func (g *Game) RunCellularAutomataCave(h, w int) bool {
 	d := &dungeon{}
 	d.Cells = make([]cell, h*w)
 	d.Width = w
 	d.Heigth = h
 	for i := range d.Cells {
-		r := RandInt(100)
+		r := g.Rand.Int(100)
 		pos := d.CellPosition(i)
 		if r >= 45 {
 			d.SetCell(pos, FreeCell)
@@ -626,11 +626,11 @@ func (g *game) RunCellularAutomataCave(h, w int) bool {
 		}
 		d.Cells = bufm.Cells
 	}
-	var conn map[position]bool
+	var conn map[Position]bool
 	var count int
-	var winner position
+	var winner Position
 	for i := 0; i < 15; i++ {
-		pos := d.FreeCell()
+		pos := d.FreeCell(&g.Rand)
 		if conn[pos] {
 			continue
 		}
@@ -667,8 +667,8 @@ loop:
 		if i > 1000 {
 			break
 		}
-		diag := RandInt(2) == 0
-		block := d.DigBlock(diag)
+		diag := g.Rand.Int(2) == 0
+		block := d.DigBlock(&g.Rand, diag)
 		if len(block) == 0 {
 			continue loop
 		}
@@ -685,7 +685,7 @@ loop:
 	return true
 }
 
-func (g *game) GenCellularAutomataCaveMap(h, w int) {
+func (g *Game) GenCellularAutomataCaveMap(h, w int) {
 	count := 0
 	for {
 		count++
@@ -1,53 +1,63 @@
-package main
+package boohu
 
 import "testing"
 
 func TestCellularAutomataCaveMap(t *testing.T) {
 	for i := 0; i < 100; i++ {
-		g := &game{}
+		g := &Game{}
+		seed := RandomSeed()
+		g.SetSeed(seed)
 		g.GenCellularAutomataCaveMap(21, 79)
-		if !g.Dungeon.connex() {
-			t.Errorf("Not connex: %+v\n", g.Dungeon.Cells)
+		if !g.Dungeon.connex(&g.Rand) {
+			t.Errorf("Not connex (seed %d): %+v\n", seed, g.Dungeon.Cells)
 		}
 	}
 }
 
 func TestCaveMap(t *testing.T) {
 	for i := 0; i < 100; i++ {
-		g := &game{}
+		g := &Game{}
+		seed := RandomSeed()
+		g.SetSeed(seed)
 		g.GenCaveMap(21, 79)
-		if !g.Dungeon.connex() {
-			t.Errorf("Not connex: %+v\n", g.Dungeon.Cells)
+		if !g.Dungeon.connex(&g.Rand) {
+			t.Errorf("Not connex (seed %d): %+v\n", seed, g.Dungeon.Cells)
 		}
 	}
 }
 
 func TestCaveMapTree(t *testing.T) {
 	for i := 0; i < 100; i++ {
-		g := &game{}
+		g := &Game{}
+		seed := RandomSeed()
+		g.SetSeed(seed)
 		g.GenCaveMapTree(21, 79)
-		if !g.Dungeon.connex() {
-			t.Errorf("Not connex: %+v\n", g.Dungeon.Cells)
+		if !g.Dungeon.connex(&g.Rand) {
+			t.Errorf("Not connex (seed %d): %+v\n", seed, g.Dungeon.Cells)
 		}
 	}
 }
 
 func TestRuinsMap(t *testing.T) {
 	for i := 0; i < 100; i++ {
-		g := &game{}
+		g := &Game{}
+		seed := RandomSeed()
+		g.SetSeed(seed)
 		g.GenRuinsMap(21, 79)
-		if !g.Dungeon.connex() {
-			t.Errorf("Not connex: %+v\n", g.Dungeon.Cells)
+		if !g.Dungeon.connex(&g.Rand) {
+			t.Errorf("Not connex (seed %d): %+v\n", seed, g.Dungeon.Cells)
 		}
 	}
 }
 
 func TestRoomMap(t *testing.T) {
 	for i := 0; i < 100; i++ {
-		g := &game{}
+		g := &Game{}
+		seed := RandomSeed()
+		g.SetSeed(seed)
 		g.GenRoomMap(21, 79)
-		if !g.Dungeon.connex() {
-			t.Errorf("Not connex: %+v\n", g.Dungeon.Cells)
+		if !g.Dungeon.connex(&g.Rand) {
+			t.Errorf("Not connex (seed %d): %+v\n", seed, g.Dungeon.Cells)
 		}
 	}
 }
@@ -1,20 +1,34 @@
-package main
+package boohu
 
 import "container/heap"
 
-type event interface {
+type Event interface {
 	Rank() int
-	Action(*game)
-	Renew(*game, int)
+	Action(*Game)
+	Renew(*Game, int)
+	Seq() int
+	SetSeq(int)
 }
 
-type eventQueue []event
+type eventQueue []Event
+
+// PushEvent adds an event to the queue. Events of equal rank are then
+// handled in the order they were pushed.
+func (g *Game) PushEvent(ev Event) {
+	g.EventSeq++
+	ev.SetSeq(g.EventSeq)
+	heap.Push(g.Events, ev)
+}
 
 func (evq eventQueue) Len() int {
 	return len(evq)
 }
 
 func (evq eventQueue) Less(i, j int) bool {
+	if evq[i].Rank() == evq[j].Rank() {
+		// events of equal rank happen in insertion order
+		return evq[i].Seq() < evq[j].Seq()
+	}
 	return evq[i].Rank() < evq[j].Rank()
 }
 
@@ -23,7 +37,7 @@ func (evq eventQueue) Swap(i, j int) {
 }
 
 func (evq *eventQueue) Push(x interface{}) {
-	no := x.(event)
+	no := x.(Event)
 	*evq = append(*evq, no)
 }
 
@@ -57,20 +71,30 @@ const (
 type simpleEvent struct {
 	ERank   int
 	EAction simpleAction
+	ESeq    int
 }
 
 func (sev *simpleEvent) Rank() int {
 	return sev.ERank
 }
 
-func (sev *simpleEvent) Renew(g *game, delay int) {
+func (sev *simpleEvent) Seq() int {
+	return sev.ESeq
+}
+
+func (sev *simpleEvent) SetSeq(seq int) {
+	sev.ESeq = seq
+}
+
+func (sev *simpleEvent) Renew(g *Game, delay int) {
 	sev.ERank += delay
-	heap.Push(g.Events, sev)
+	g.PushEvent(sev)
 }
 
-func (sev *simpleEvent) Action(g *game) {
+func (sev *simpleEvent) Action(g *Game) {
 	switch sev.EAction {
 	case PlayerTurn:
+		g.snapshot(sev)
 		g.AutoNext = g.AutoPlayer(sev)
 		if g.AutoNext {
 			return
@@ -90,8 +114,8 @@ func (sev *simpleEvent) Action(g *game) {
 		g.Player.Statuses[StatusSlow]++
 		g.Player.Statuses[StatusExhausted]++
 		g.Print("You are no longer berserk.")
-		heap.Push(g.Events, &simpleEvent{ERank: sev.Rank() + 90 + RandInt(40), EAction: SlowEnd})
-		heap.Push(g.Events, &simpleEvent{ERank: sev.Rank() + 270 + RandInt(60), EAction: ExhaustionEnd})
+		g.PushEvent(&simpleEvent{ERank: sev.Rank() + 90 + g.Rand.Int(40), EAction: SlowEnd})
+		g.PushEvent(&simpleEvent{ERank: sev.Rank() + 270 + g.Rand.Int(60), EAction: ExhaustionEnd})
 	case SlowEnd:
 		g.Print("You feel no longer slow.")
 		g.Player.Statuses[StatusSlow]--
@@ -135,43 +159,45 @@ const (
 
 type monsterEvent struct {
 	ERank   int
-	NMons   int
+	MonsID  int
 	EAction monsterAction
+	NMons   int // index of the monster in g.Monsters, only in older saves
+	ESeq    int
 }
 
 func (mev *monsterEvent) Rank() int {
 	return mev.ERank
 }
 
-func (mev *monsterEvent) Action(g *game) {
+func (mev *monsterEvent) Seq() int {
+	return mev.ESeq
+}
+
+func (mev *monsterEvent) SetSeq(seq int) {
+	mev.ESeq = seq
+}
+
+func (mev *monsterEvent) Action(g *Game) {
+	mons := g.MonsterByID(mev.MonsID)
+	if !mons.Exists() {
+		return
+	}
 	switch mev.EAction {
 	case MonsterTurn:
-		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.HandleTurn(g, mev)
-		}
+		mons.HandleTurn(g, mev)
 	case HealMonster:
-		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.Heal(g, mev)
-		}
+		mons.Heal(g, mev)
 	case MonsConfusionEnd:
-		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.Statuses[MonsConfused]--
-			g.Printf("The %s is no longer confused.", mons.Kind)
-		}
+		mons.Statuses[MonsConfused]--
+		g.Printf("The %s is no longer confused.", mons.Kind)
 	case MonsExhaustionEnd:
-		mons := g.Monsters[mev.NMons]
-		if mons.Exists() {
-			mons.Statuses[MonsExhausted]--
-		}
+		mons.Statuses[MonsExhausted]--
 	}
 }
 
-func (mev *monsterEvent) Renew(g *game, delay int) {
+func (mev *monsterEvent) Renew(g *Game, delay int) {
 	mev.ERank += delay
-	heap.Push(g.Events, mev)
+	g.PushEvent(mev)
 }
 
 type cloudAction int
@@ -182,15 +208,24 @@ const (
 
 type cloudEvent struct {
 	ERank   int
-	Pos     position
+	Pos     Position
 	EAction cloudAction
+	ESeq    int
 }
 
 func (cev *cloudEvent) Rank() int {
 	return cev.ERank
 }
 
-func (cev *cloudEvent) Action(g *game) {
+func (cev *cloudEvent) Seq() int {
+	return cev.ESeq
+}
+
+func (cev *cloudEvent) SetSeq(seq int) {
+	cev.ESeq = seq
+}
+
+func (cev *cloudEvent) Action(g *Game) {
 	switch cev.EAction {
 	case CloudEnd:
 		delete(g.Clouds, cev.Pos)
@@ -198,7 +233,7 @@ func (cev *cloudEvent) Action(g *game) {
 	}
 }
 
-func (cev *cloudEvent) Renew(g *game, delay int) {
+func (cev *cloudEvent) Renew(g *Game, delay int) {
 	cev.ERank += delay
-	heap.Push(g.Events, cev)
+	g.PushEvent(cev)
 }
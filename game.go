@@ -1,4 +1,7 @@
-package main
+// Package boohu implements the Break Out Of Hareka's Underground game: dungeon
+// generation, monsters, items and the event loop. Drawing and input are left
+// to a Renderer; the terminal interface lives in cmd/boohu.
+package boohu
 
 import "container/heap"
 
@@ -6,53 +9,86 @@ import "container/heap"
 // and not exploring new cells and in good health
 // monster induced berserker
 
-type game struct {
+type Game struct {
 	Dungeon             *dungeon
 	Player              *player
-	Monsters            []*monster
+	Monsters            []*Monster
+	LastMonsterID       int
+	monstersByID        map[int]*Monster
 	Bands               []monsterBand
 	Events              *eventQueue
-	Highlight           map[position]bool // highlighted positions (e.g. targeted ray)
-	Collectables        map[position]*collectable
+	EventSeq            int               // sequence number of the last pushed event
+	Highlight           map[Position]bool // highlighted positions (e.g. targeted ray)
+	Collectables        map[Position]*collectable
 	CollectableScore    int
-	Equipables          map[position]equipable
-	Rods                map[position]rod
-	Stairs              map[position]bool
-	Clouds              map[position]cloud
+	Equipables          map[Position]equipable
+	Rods                map[Position]rod
+	Stairs              map[Position]bool
+	Clouds              map[Position]cloud
 	GeneratedBands      map[monsterBand]int
 	GeneratedEquipables map[equipable]bool
 	GeneratedRods       map[rod]bool
 	FoundEquipables     map[equipable]bool
-	Gold                map[position]int
-	UnknownDig          map[position]bool
+	Gold                map[Position]int
+	UnknownDig          map[Position]bool
 	Resting             bool
 	Autoexploring       bool
 	AutoexploreMap      nodeMap
-	AutoTarget          *position
+	AutoTarget          *Position
 	AutoHalt            bool
 	AutoNext            bool
-	ExclusionsMap       map[position]bool
+	ExclusionsMap       map[Position]bool
 	Quit                bool
+	Abandoned           bool // quit without saving
 	ui                  Renderer
+	Name                string
 	Depth               int
 	Wizard              bool
+	Daily               string // date of the daily challenge, or empty for a normal game
 	Log                 []string
 	Story               []string
 	Turn                int
 	Killed              int
 	KilledMons          map[monsterKind]int
+	Killer              string
+	KilledBy            DamageEvent   // the deadly hit
+	Damages             []DamageEvent // last hits taken
+	Used                map[consumable]int
+	Evoked              map[rod]int
 	Scumming            int
+	Seed                int64
+	Rand                rng
+	Inputs              []ReplayInput
+	CriticalHP          int     // HP under which the player is warned, or 0 for the damage of the attack
+	StartCriticalHP     int     // CriticalHP before it was first changed, for replays
+	Recoveries          int     // times the game was recovered after not ending properly
+	AutoSaved           bool    // saved without the player asking, when the terminal was closed
+	noSave              bool    // replays must not touch the player's files
+	morgueFile          string  // where the dump was archived when the game ended
+	noiseMap            nodeMap // last noise, for wizard overlays
+	noiseLevel          int
+	snapshots           []snapshot // states of the last player turns, in wizard mode
+	rewound             bool       // rewound during the current player turn
+}
+
+// Version is the version of the game, recorded in save files.
+const Version = "v0.1-dev"
+
+// NewGame returns a game drawn by ui. The game has to be either loaded or
+// initialized with SetSeed and InitLevel before running its EventLoop.
+func NewGame(ui Renderer) *Game {
+	return &Game{ui: ui}
 }
 
 type Renderer interface {
-	ExploreStep(*game) bool
-	HandlePlayerTurn(*game, event) bool
-	Death(*game)
-	ChooseTarget(*game, Targetter) bool
-	CriticalHPWarning(*game)
+	ExploreStep(*Game) bool
+	RestStep(*Game) bool
+	HandlePlayerTurn(*Game, Event) bool
+	Death(*Game)
+	CriticalHPWarning(*Game)
 }
 
-func (g *game) FreeCell() position {
+func (g *Game) FreeCell() Position {
 	m := g.Dungeon
 	count := 0
 	for {
@@ -60,15 +96,15 @@ func (g *game) FreeCell() position {
 		if count > 1000 {
 			panic("FreeCell")
 		}
-		x := RandInt(m.Width)
-		y := RandInt(m.Heigth)
-		pos := position{x, y}
+		x := g.Rand.Int(m.Width)
+		y := g.Rand.Int(m.Heigth)
+		pos := Position{x, y}
 		c := m.Cell(pos)
 		if c.T == FreeCell {
 			if g.Player != nil && g.Player.Pos == pos {
 				continue
 			}
-			mons, _ := g.MonsterAt(pos)
+			mons := g.MonsterAt(pos)
 			if mons.Exists() {
 				continue
 			}
@@ -77,7 +113,7 @@ func (g *game) FreeCell() position {
 	}
 }
 
-func (g *game) FreeCellForImportantStair() position {
+func (g *Game) FreeCellForImportantStair() Position {
 	for {
 		pos := g.FreeCellForStatic()
 		if pos.Distance(g.Player.Pos) > 12 {
@@ -86,7 +122,7 @@ func (g *game) FreeCellForImportantStair() position {
 	}
 }
 
-func (g *game) FreeCellForStatic() position {
+func (g *Game) FreeCellForStatic() Position {
 	m := g.Dungeon
 	count := 0
 	for {
@@ -94,15 +130,15 @@ func (g *game) FreeCellForStatic() position {
 		if count > 1000 {
 			panic("FreeCellForStatic")
 		}
-		x := RandInt(m.Width)
-		y := RandInt(m.Heigth)
-		pos := position{x, y}
+		x := g.Rand.Int(m.Width)
+		y := g.Rand.Int(m.Heigth)
+		pos := Position{x, y}
 		c := m.Cell(pos)
 		if c.T == FreeCell {
 			if g.Player != nil && g.Player.Pos == pos {
 				continue
 			}
-			mons, _ := g.MonsterAt(pos)
+			mons := g.MonsterAt(pos)
 			if mons.Exists() {
 				continue
 			}
@@ -126,7 +162,7 @@ func (g *game) FreeCellForStatic() position {
 	}
 }
 
-func (g *game) FreeCellForMonster() position {
+func (g *Game) FreeCellForMonster() Position {
 	m := g.Dungeon
 	count := 0
 	for {
@@ -134,15 +170,15 @@ func (g *game) FreeCellForMonster() position {
 		if count > 1000 {
 			panic("FreeCellForMonster")
 		}
-		x := RandInt(m.Width)
-		y := RandInt(m.Heigth)
-		pos := position{x, y}
+		x := g.Rand.Int(m.Width)
+		y := g.Rand.Int(m.Heigth)
+		pos := Position{x, y}
 		c := m.Cell(pos)
 		if c.T == FreeCell {
 			if g.Player != nil && g.Player.Pos.Distance(pos) < 8 {
 				continue
 			}
-			mons, _ := g.MonsterAt(pos)
+			mons := g.MonsterAt(pos)
 			if mons.Exists() {
 				continue
 			}
@@ -151,7 +187,7 @@ func (g *game) FreeCellForMonster() position {
 	}
 }
 
-func (g *game) FreeCellForBandMonster(pos position) position {
+func (g *Game) FreeCellForBandMonster(pos Position) Position {
 	count := 0
 	for {
 		count++
@@ -159,12 +195,12 @@ func (g *game) FreeCellForBandMonster(pos position) position {
 			panic("FreeCellForBandMonster")
 		}
 		neighbors := g.Dungeon.FreeNeighbors(pos)
-		r := RandInt(len(neighbors))
+		r := g.Rand.Int(len(neighbors))
 		pos = neighbors[r]
 		if g.Player != nil && g.Player.Pos.Distance(pos) < 8 {
 			continue
 		}
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons.Exists() {
 			continue
 		}
@@ -172,7 +208,7 @@ func (g *game) FreeCellForBandMonster(pos position) position {
 	}
 }
 
-func (g *game) FreeForStairs() position {
+func (g *Game) FreeForStairs() Position {
 	m := g.Dungeon
 	count := 0
 	for {
@@ -180,9 +216,9 @@ func (g *game) FreeForStairs() position {
 		if count > 1000 {
 			panic("FreeForStairs")
 		}
-		x := RandInt(m.Width)
-		y := RandInt(m.Heigth)
-		pos := position{x, y}
+		x := g.Rand.Int(m.Width)
+		y := g.Rand.Int(m.Heigth)
+		pos := Position{x, y}
 		c := m.Cell(pos)
 		if c.T == FreeCell {
 			_, ok := g.Collectables[pos]
@@ -194,12 +230,32 @@ func (g *game) FreeForStairs() position {
 	}
 }
 
-func (g *game) MaxDepth() int {
+func (g *Game) MaxDepth() int {
 	return 12
 }
 
-func (g *game) GenDungeon() {
-	switch RandInt(6) {
+// Won reports whether the player escaped from the dungeon.
+func (g *Game) Won() bool {
+	return g.Player.HP > 0 && g.Depth > g.MaxDepth()
+}
+
+// Outcome returns Won, Died or Abandoned for finished games, and Unfinished
+// otherwise.
+func (g *Game) Outcome() outcome {
+	switch {
+	case g.Won():
+		return Won
+	case g.Player.HP <= 0:
+		return Died
+	case g.Abandoned:
+		return Abandoned
+	default:
+		return Unfinished
+	}
+}
+
+func (g *Game) GenDungeon() {
+	switch g.Rand.Int(6) {
 	case 0:
 		g.GenCaveMap(21, 79)
 	case 1:
@@ -213,7 +269,7 @@ func (g *game) GenDungeon() {
 	}
 }
 
-func (g *game) InitLevel() {
+func (g *Game) InitLevel() {
 	// Dungeon terrain
 	g.GenDungeon()
 
@@ -229,7 +285,7 @@ func (g *game) InitLevel() {
 			HealWoundsPotion: 1,
 			Javelin:          3,
 		}
-		switch RandInt(6) {
+		switch g.Rand.Int(6) {
 		case 0, 1:
 			g.Player.Consumables[TeleportationPotion] = 1
 		case 2, 3:
@@ -249,31 +305,31 @@ func (g *game) InitLevel() {
 	}
 	g.Player.Pos = g.FreeCell()
 
-	g.UnknownDig = map[position]bool{}
-	g.ExclusionsMap = map[position]bool{}
+	g.UnknownDig = map[Position]bool{}
+	g.ExclusionsMap = map[Position]bool{}
 
 	// Monsters
 	g.GenMonsters()
 
 	// Collectables
-	g.Collectables = make(map[position]*collectable)
+	g.Collectables = make(map[Position]*collectable)
 	g.GenCollectables()
 
 	// Equipment
-	g.Equipables = make(map[position]equipable)
-	for eq, data := range EquipablesRepartitionData {
-		g.GenEquip(eq, data)
+	g.Equipables = make(map[Position]equipable)
+	for _, eq := range SortedEquipables() {
+		g.GenEquip(eq, EquipablesRepartitionData[eq])
 	}
 
 	// Rods
-	g.Rods = map[position]rod{}
+	g.Rods = map[Position]rod{}
 	r := 7*(g.GeneratedRodsCount()+1) - 2*(g.Depth+1)
 	if r < -3 {
 		r = 0
 	} else if r < 2 {
 		r = 1
 	}
-	if RandInt(r) == 0 && g.GeneratedRodsCount() < 3 {
+	if g.Rand.Int(r) == 0 && g.GeneratedRodsCount() < 3 {
 		g.GenerateRod()
 	}
 
@@ -282,7 +338,7 @@ func (g *game) InitLevel() {
 	if r < 2 {
 		r = 1
 	}
-	if RandInt(r) == 0 && g.Depth > 0 && g.Player.AptitudeCount() < 3 {
+	if g.Rand.Int(r) == 0 && g.Depth > 0 && g.Player.AptitudeCount() < 3 {
 		apt, ok := g.RandomApt()
 		if ok {
 			g.ApplyAptitude(apt)
@@ -290,15 +346,15 @@ func (g *game) InitLevel() {
 	}
 
 	// Stairs
-	g.Stairs = make(map[position]bool)
-	nstairs := 1 + RandInt(3)
+	g.Stairs = make(map[Position]bool)
+	nstairs := 1 + g.Rand.Int(3)
 	if g.Depth == g.MaxDepth() {
 		nstairs = 1
 	} else if g.Depth == g.MaxDepth()-1 && nstairs > 2 {
-		nstairs = 1 + RandInt(2)
+		nstairs = 1 + g.Rand.Int(2)
 	}
 	for i := 0; i < nstairs; i++ {
-		var pos position
+		var pos Position
 		if g.Depth > 9 {
 			pos = g.FreeCellForImportantStair()
 		} else {
@@ -308,10 +364,10 @@ func (g *game) InitLevel() {
 	}
 
 	// Gold
-	g.Gold = make(map[position]int)
+	g.Gold = make(map[Position]int)
 	for i := 0; i < 5; i++ {
 		pos := g.FreeCellForStatic()
-		g.Gold[pos] = 1 + RandInt(g.Depth+g.Depth*g.Depth/10)
+		g.Gold[pos] = 1 + g.Rand.Int(g.Depth+g.Depth*g.Depth/10)
 	}
 
 	// initialize LOS
@@ -325,9 +381,10 @@ func (g *game) InitLevel() {
 	g.MakeMonstersAware()
 
 	// recharge rods
-	for r, props := range g.Player.Rods {
+	for _, r := range g.SortedRods() {
+		props := g.Player.Rods[r]
 		if props.Charge < r.MaxCharge() {
-			props.Charge += RandInt(1 + r.Rate())
+			props.Charge += g.Rand.Int(1 + r.Rate())
 		}
 		if props.Charge > r.MaxCharge() {
 			props.Charge = r.MaxCharge()
@@ -335,28 +392,28 @@ func (g *game) InitLevel() {
 	}
 
 	// clouds
-	g.Clouds = map[position]cloud{}
+	g.Clouds = map[Position]cloud{}
 
 	// Events
 	if g.Depth == 0 {
 		g.Events = &eventQueue{}
 		heap.Init(g.Events)
-		heap.Push(g.Events, &simpleEvent{ERank: 0, EAction: PlayerTurn})
-		heap.Push(g.Events, &simpleEvent{ERank: 50, EAction: HealPlayer})
-		heap.Push(g.Events, &simpleEvent{ERank: 100, EAction: MPRegen})
+		g.PushEvent(&simpleEvent{ERank: 0, EAction: PlayerTurn})
+		g.PushEvent(&simpleEvent{ERank: 50, EAction: HealPlayer})
+		g.PushEvent(&simpleEvent{ERank: 100, EAction: MPRegen})
 	} else {
 		g.CleanEvents()
 	}
-	for i := range g.Monsters {
-		heap.Push(g.Events, &monsterEvent{ERank: g.Turn + 1, EAction: MonsterTurn, NMons: i})
-		heap.Push(g.Events, &monsterEvent{ERank: g.Turn + 50, EAction: HealMonster, NMons: i})
+	for _, mons := range g.Monsters {
+		g.PushEvent(&monsterEvent{ERank: g.Turn + 1, EAction: MonsterTurn, MonsID: mons.ID})
+		g.PushEvent(&monsterEvent{ERank: g.Turn + 50, EAction: HealMonster, MonsID: mons.ID})
 	}
 }
 
-func (g *game) CleanEvents() {
+func (g *Game) CleanEvents() {
 	evq := &eventQueue{}
 	for g.Events.Len() > 0 {
-		ev := heap.Pop(g.Events).(event)
+		ev := heap.Pop(g.Events).(Event)
 		switch ev.(type) {
 		case *monsterEvent:
 		case *cloudEvent:
@@ -367,17 +424,18 @@ func (g *game) CleanEvents() {
 	g.Events = evq
 }
 
-func (g *game) GenCollectables() {
+func (g *Game) GenCollectables() {
 	rounds := 10
 	for i := 0; i < rounds; i++ {
-		for c, data := range ConsumablesCollectData {
+		for _, c := range SortedCollectables() {
+			data := ConsumablesCollectData[c]
 			var r int
 			if g.CollectableScore >= 5*(g.Depth+1)/3 {
-				r = RandInt(data.rarity * rounds * 4)
+				r = g.Rand.Int(data.rarity * rounds * 4)
 			} else if g.CollectableScore < 4*(g.Depth+1)/3 {
-				r = RandInt(data.rarity * rounds / 4)
+				r = g.Rand.Int(data.rarity * rounds / 4)
 			} else {
-				r = RandInt(data.rarity * rounds)
+				r = g.Rand.Int(data.rarity * rounds)
 			}
 
 			if r == 0 {
@@ -389,43 +447,43 @@ func (g *game) GenCollectables() {
 	}
 }
 
-func (g *game) SeenGoodWeapon() bool {
+func (g *Game) SeenGoodWeapon() bool {
 	return g.GeneratedEquipables[Sword] || g.GeneratedEquipables[DoubleSword] || g.GeneratedEquipables[Spear] || g.GeneratedEquipables[Halberd] ||
 		g.GeneratedEquipables[Axe] || g.GeneratedEquipables[BattleAxe]
 }
 
-func (g *game) GenEquip(eq equipable, data equipableData) {
+func (g *Game) GenEquip(eq equipable, data equipableData) {
 	depthAdjust := data.minDepth - g.Depth
 	var r int
 	if depthAdjust >= 0 {
-		r = RandInt(data.rarity * (depthAdjust + 1) * (depthAdjust + 1))
+		r = g.Rand.Int(data.rarity * (depthAdjust + 1) * (depthAdjust + 1))
 	} else {
 		switch eq.(type) {
 		case shield:
 			if !g.GeneratedEquipables[eq] {
-				r = data.FavorableRoll(-depthAdjust)
+				r = data.FavorableRoll(&g.Rand, -depthAdjust)
 			} else {
-				r = RandInt(data.rarity * 2)
+				r = g.Rand.Int(data.rarity * 2)
 			}
 		case armour:
 			if !g.GeneratedEquipables[eq] && eq != Robe {
-				r = data.FavorableRoll(-depthAdjust)
+				r = data.FavorableRoll(&g.Rand, -depthAdjust)
 			} else {
-				r = RandInt(data.rarity * 2)
+				r = g.Rand.Int(data.rarity * 2)
 			}
 		case weapon:
 			if !g.SeenGoodWeapon() && eq != Dagger {
-				r = data.FavorableRoll(-depthAdjust)
+				r = data.FavorableRoll(&g.Rand, -depthAdjust)
 			} else {
 				if g.Player.Weapon != Dagger {
-					r = RandInt(data.rarity * 4)
+					r = g.Rand.Int(data.rarity * 4)
 				} else {
-					r = RandInt(data.rarity * 2)
+					r = g.Rand.Int(data.rarity * 2)
 				}
 			}
 		default:
 			// not reached
-			r = RandInt(data.rarity)
+			r = g.Rand.Int(data.rarity)
 		}
 	}
 	if r == 0 {
@@ -436,24 +494,23 @@ func (g *game) GenEquip(eq equipable, data equipableData) {
 
 }
 
-func (g *game) Descend(ev event) bool {
+func (g *Game) Descend(ev Event) {
 	if g.Depth >= g.MaxDepth() {
 		g.Depth++
 		// win
 		g.RemoveSaveFile()
-		return true
+		return
 	}
 	g.Print("You descend deeper in the dungeon.")
 	g.Depth++
-	heap.Push(g.Events, &simpleEvent{ERank: ev.Rank(), EAction: PlayerTurn})
+	g.PushEvent(&simpleEvent{ERank: ev.Rank(), EAction: PlayerTurn})
 	g.InitLevel()
 	g.Save()
-	return false
 }
 
-func (g *game) AutoPlayer(ev event) bool {
+func (g *Game) AutoPlayer(ev Event) bool {
 	if g.Resting {
-		if g.MonsterInLOS() == nil &&
+		if !g.ui.RestStep(g) && g.MonsterInLOS() == nil &&
 			(g.Player.HP < g.Player.HPMax() || g.Player.MP < g.Player.MPMax() || g.Player.HasStatus(StatusExhausted) ||
 				g.Player.HasStatus(StatusConfusion) || g.Player.HasStatus(StatusLignification)) {
 			g.WaitTurn(ev)
@@ -516,7 +573,7 @@ func (g *game) AutoPlayer(ev event) bool {
 	return false
 }
 
-func (g *game) EventLoop() {
+func (g *Game) EventLoop() {
 loop:
 	for {
 		if g.Player.HP <= 0 {
@@ -531,7 +588,7 @@ loop:
 		if g.Events.Len() == 0 {
 			break loop
 		}
-		ev := heap.Pop(g.Events).(event)
+		ev := heap.Pop(g.Events).(Event)
 		g.Turn = ev.Rank()
 		ev.Action(g)
 		if g.AutoNext {
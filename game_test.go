@@ -0,0 +1,140 @@
+package boohu
+
+import (
+	"container/heap"
+	"reflect"
+	"testing"
+)
+
+func TestSeedReproducible(t *testing.T) {
+	for _, seed := range []int64{1, 42, RandomSeed()} {
+		g1 := &Game{}
+		g1.SetSeed(seed)
+		g2 := &Game{}
+		g2.SetSeed(seed)
+		for depth := 0; depth < 4; depth++ {
+			g1.Depth = depth
+			g2.Depth = depth
+			g1.InitLevel()
+			g2.InitLevel()
+			if !reflect.DeepEqual(g1.Dungeon, g2.Dungeon) {
+				t.Errorf("Different dungeons for seed %d at depth %d", seed, depth)
+			}
+			if !reflect.DeepEqual(g1.Monsters, g2.Monsters) {
+				t.Errorf("Different monsters for seed %d at depth %d", seed, depth)
+			}
+			if !reflect.DeepEqual(g1.Collectables, g2.Collectables) || !reflect.DeepEqual(g1.Equipables, g2.Equipables) {
+				t.Errorf("Different items for seed %d at depth %d", seed, depth)
+			}
+			if g1.Player.Pos != g2.Player.Pos {
+				t.Errorf("Different player positions for seed %d at depth %d", seed, depth)
+			}
+		}
+	}
+}
+
+func TestDoInvalidActions(t *testing.T) {
+	g, _ := newHeadlessGame(3, "")
+	ev := &simpleEvent{EAction: PlayerTurn}
+	turn := g.Turn
+	g.Stairs[g.Player.Pos] = false
+	invalid := []Action{
+		{Kind: DescendAction},
+		{Kind: EvokeAction, Rod: RodFireball},
+		{Kind: ThrowAction, Projectile: Net},
+		{Kind: TravelAction, Target: g.Player.Pos},
+		{Kind: TravelAction, Target: Position{0, -1}},
+		{Kind: TravelAction, Target: Position{g.Dungeon.Width, g.Dungeon.Heigth}},
+		{Kind: ThrowAction, Projectile: Javelin, Target: Position{g.Dungeon.Width, 0}},
+	}
+	g.Player.Consumables[Javelin] = 1
+	for _, a := range invalid {
+		if err := g.Do(a, ev); err == nil {
+			t.Errorf("Action %+v should have failed", a)
+		}
+	}
+	if ev.Rank() != turn {
+		t.Errorf("Invalid actions spent time")
+	}
+	if err := g.Do(Action{Kind: WaitAction}, ev); err != nil {
+		t.Errorf("Wait failed: %v", err)
+	}
+	if ev.Rank() == turn {
+		t.Errorf("Waiting did not spend time")
+	}
+}
+
+func TestBaselineBot(t *testing.T) {
+	res := PlayBot(BaselineBot{}, 5, 20000)
+	if res.Turns == 0 {
+		t.Errorf("No turns were played")
+	}
+	if res.Outcome == Died && res.Killer == "" {
+		t.Errorf("Unknown killer")
+	}
+	if res != PlayBot(BaselineBot{}, 5, 20000) {
+		t.Errorf("Different results for the same seed")
+	}
+}
+
+func TestEventOrder(t *testing.T) {
+	g := &Game{Events: &eventQueue{}}
+	for i := 0; i < 20; i++ {
+		g.PushEvent(&monsterEvent{ERank: 10 * (i % 2), MonsID: i})
+	}
+	prev := heap.Pop(g.Events).(*monsterEvent)
+	for g.Events.Len() > 0 {
+		ev := heap.Pop(g.Events).(*monsterEvent)
+		if ev.Rank() == prev.Rank() && ev.MonsID < prev.MonsID || ev.Rank() < prev.Rank() {
+			t.Errorf("Event %d popped after event %d", ev.MonsID, prev.MonsID)
+		}
+		prev = ev
+	}
+}
+
+func TestSetCriticalHP(t *testing.T) {
+	g, _ := newHeadlessGame(3, "")
+	g.SetCriticalHP(0)
+	if len(g.Inputs) != 0 {
+		t.Errorf("Unchanged CriticalHP recorded")
+	}
+	// a game started with a warning at 5 HP, continued with other settings
+	g.CriticalHP = 5
+	g.SetCriticalHP(10)
+	g.SetCriticalHP(7)
+	if g.CriticalHP != 7 || len(g.Inputs) != 2 || g.Inputs[1].HP != 7 {
+		t.Errorf("Bad CriticalHP %d, inputs %+v", g.CriticalHP, g.Inputs)
+	}
+	if hp := g.replayCriticalHP(); hp != 5 {
+		t.Errorf("Replay starts with CriticalHP %d instead of 5", hp)
+	}
+}
+
+// restStopper stops resting after a number of turns, like the terminal
+// interface does when the game has to be saved.
+type restStopper struct {
+	*headless
+	turns int
+}
+
+func (r *restStopper) RestStep(g *Game) bool {
+	r.turns--
+	return r.turns < 0
+}
+
+func TestRestStep(t *testing.T) {
+	g, h := newHeadlessGame(3, "")
+	g.ui = &restStopper{headless: h, turns: 2}
+	g.Monsters = nil
+	g.Player.HP = 1
+	g.Resting = true
+	ev := &simpleEvent{EAction: PlayerTurn}
+	for i := 0; i < 2; i++ {
+		if !g.AutoPlayer(ev) {
+			t.Fatalf("Rest stopped after %d turns", i)
+		}
+	}
+	if g.AutoPlayer(ev) || g.Resting {
+		t.Errorf("Rest not stopped by the renderer")
+	}
+}
@@ -0,0 +1,208 @@
+package boohu
+
+import (
+	"errors"
+	"sort"
+)
+
+type outcome int
+
+const (
+	Unfinished outcome = iota
+	Died
+	Won
+	ScriptEnd
+	Abandoned
+)
+
+func (o outcome) String() (text string) {
+	switch o {
+	case Unfinished:
+		text = "unfinished"
+	case Died:
+		text = "died"
+	case Won:
+		text = "won"
+	case ScriptEnd:
+		text = "end of script"
+	case Abandoned:
+		text = "abandoned"
+	}
+	return text
+}
+
+// headless is a Renderer that plays a script of commands without any
+// terminal, recording every message and the outcome of the game. The
+// commands use the same keys as in the terminal interface. Item selection
+// keys ('q', 't' and 'v') are followed by the letter of the item in the
+// menu, and targets are chosen automatically, closest first.
+type headless struct {
+	Messages []string
+	Outcome  outcome
+	script   []rune
+	index    int
+	logged   int
+}
+
+func newHeadlessGame(seed int64, script string) (*Game, *headless) {
+	g := &Game{noSave: true}
+	h := &headless{script: []rune(script)}
+	g.SetSeed(seed)
+	g.InitLevel()
+	g.ui = h
+	return g, h
+}
+
+// Sync records messages printed since the last call.
+func (h *headless) Sync(g *Game) {
+	if len(g.Log) < h.logged {
+		// the log was shortened by Print
+		h.logged -= 500
+		if h.logged < 0 {
+			h.logged = 0
+		}
+	}
+	h.Messages = append(h.Messages, g.Log[h.logged:]...)
+	h.logged = len(g.Log)
+}
+
+func (h *headless) next() (rune, bool) {
+	if h.index >= len(h.script) {
+		return 0, false
+	}
+	h.index++
+	return h.script[h.index-1], true
+}
+
+func (h *headless) ExploreStep(g *Game) bool {
+	h.Sync(g)
+	return false
+}
+
+func (h *headless) RestStep(g *Game) bool {
+	return false
+}
+
+func (h *headless) HandlePlayerTurn(g *Game, ev Event) bool {
+	for {
+		h.Sync(g)
+		key, ok := h.next()
+		if !ok {
+			h.Outcome = ScriptEnd
+			return true
+		}
+		var a Action
+		var err error
+		switch key {
+		case 'h', '4':
+			a = Action{Kind: MoveAction, Dir: W}
+		case 'l', '6':
+			a = Action{Kind: MoveAction, Dir: E}
+		case 'j', '2':
+			a = Action{Kind: MoveAction, Dir: S}
+		case 'k', '8':
+			a = Action{Kind: MoveAction, Dir: N}
+		case 'y', '7':
+			a = Action{Kind: MoveAction, Dir: NW}
+		case 'b', '1':
+			a = Action{Kind: MoveAction, Dir: SW}
+		case 'u', '9':
+			a = Action{Kind: MoveAction, Dir: NE}
+		case 'n', '3':
+			a = Action{Kind: MoveAction, Dir: SE}
+		case '.', '5':
+			a = Action{Kind: WaitAction}
+		case 'r':
+			a = Action{Kind: RestAction}
+		case '>':
+			a = Action{Kind: DescendAction}
+		case 'e', 'g', ',':
+			a = Action{Kind: EquipAction}
+		case 'q', 'a':
+			ps := g.SortedPotions()
+			var i int
+			i, err = h.selectIndex(len(ps))
+			if err == nil {
+				a = Action{Kind: QuaffAction, Potion: ps[i]}
+			}
+		case 't', 'f':
+			ps := g.SortedProjectiles()
+			var i int
+			i, err = h.selectIndex(len(ps))
+			if err == nil {
+				p := ps[i]
+				a = Action{Kind: ThrowAction, Projectile: p}
+				a.Target, err = h.chooseTarget(g, p.Targetter())
+			}
+		case 'v', 'z':
+			rs := g.SortedRods()
+			var i int
+			i, err = h.selectIndex(len(rs))
+			if err == nil {
+				a = Action{Kind: EvokeAction, Rod: rs[i]}
+				if targ := rs[i].Targetter(); targ != nil {
+					a.Target, err = h.chooseTarget(g, targ)
+				}
+			}
+		case 'o':
+			a = Action{Kind: AutoexploreAction}
+		default:
+			err = errors.New("Unknown key.")
+		}
+		if err == nil {
+			err = g.Do(a, ev)
+		}
+		if err != nil {
+			g.Print(err.Error())
+			continue
+		}
+		if g.Won() {
+			h.Sync(g)
+			h.Outcome = Won
+			return true
+		}
+		return false
+	}
+}
+
+func (h *headless) selectIndex(l int) (int, error) {
+	key, ok := h.next()
+	if !ok {
+		return -1, errors.New("Ok, then.")
+	}
+	if 'a' <= key && int(key) < 'a'+l {
+		return int(key - 'a'), nil
+	}
+	return -1, errors.New("Invalid selection.")
+}
+
+func (h *headless) Death(g *Game) {
+	h.Sync(g)
+	h.Outcome = Died
+}
+
+// chooseTarget returns the first position in view, closest first, accepted
+// by the targetter.
+func (h *headless) chooseTarget(g *Game, targ Targetter) (Position, error) {
+	h.Sync(g)
+	ps := []Position{}
+	for i := range g.Dungeon.Cells {
+		pos := g.Dungeon.CellPosition(i)
+		if g.Player.LOS[pos] && pos != g.Player.Pos {
+			ps = append(ps, pos)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		return ps[i].Distance(g.Player.Pos) < ps[j].Distance(g.Player.Pos)
+	})
+	for _, pos := range ps {
+		if targ.Action(g, pos) == nil {
+			return pos, nil
+		}
+	}
+	return g.Player.Pos, errors.New("No valid target in view.")
+}
+
+func (h *headless) CriticalHPWarning(g *Game) {
+	h.Sync(g)
+}
@@ -0,0 +1,77 @@
+package boohu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeadlessScriptEnd(t *testing.T) {
+	g, h := newHeadlessGame(1, strings.Repeat("o", 30)+"r.")
+	g.EventLoop()
+	if h.Outcome != ScriptEnd {
+		t.Errorf("Bad outcome: %v", h.Outcome)
+	}
+	if g.Turn == 0 {
+		t.Errorf("No turns were played")
+	}
+	if len(h.Messages) == 0 || h.Messages[0] != "You're in Hareka's Underground. Good luck! Press ? for help." {
+		t.Errorf("Bad messages: %v", h.Messages)
+	}
+}
+
+func TestHeadlessReproducible(t *testing.T) {
+	script := strings.Repeat("o", 20) + strings.Repeat("hjkl.", 20)
+	g1, h1 := newHeadlessGame(7, script)
+	g1.EventLoop()
+	g2, h2 := newHeadlessGame(7, script)
+	g2.EventLoop()
+	if g1.Turn != g2.Turn || g1.Player.HP != g2.Player.HP || g1.Player.Pos != g2.Player.Pos {
+		t.Errorf("Different games for the same seed and script")
+	}
+	if strings.Join(h1.Messages, "\n") != strings.Join(h2.Messages, "\n") {
+		t.Errorf("Different messages for the same seed and script")
+	}
+}
+
+func TestHeadlessDeath(t *testing.T) {
+	g, h := newHeadlessGame(3, strings.Repeat(".", 1000))
+	mons := &Monster{Kind: MonsOgre}
+	mons.Init(g)
+	mons.Pos = g.Dungeon.FreeNeighbors(g.Player.Pos)[0]
+	mons.State = Hunting
+	mons.Target = g.Player.Pos
+	g.AddMonster(mons)
+	g.Bands = append(g.Bands, LoneOgre)
+	mons.Band = len(g.Bands) - 1
+	g.PushEvent(&monsterEvent{ERank: g.Turn + 1, EAction: MonsterTurn, MonsID: mons.ID})
+	g.Player.HP = 1
+	g.EventLoop()
+	if h.Outcome != Died {
+		t.Errorf("Bad outcome: %v", h.Outcome)
+	}
+	if g.Player.HP > 0 {
+		t.Errorf("Player alive with %d HP", g.Player.HP)
+	}
+	if kb := g.KilledBy; kb.Source != "an ogre" || kb.Monster != "ogre" || kb.HP != 1 || g.Killer != "an ogre" {
+		t.Errorf("Bad killing blow: %+v (killer %q)", kb, g.Killer)
+	}
+	if gs := g.GameStats(); gs.Killer != "ogre" || gs.Cause != "" {
+		t.Errorf("Bad killer in statistics: %q (cause %q)", gs.Killer, gs.Cause)
+	}
+	if !strings.Contains(g.Dump(), "You were killed by an ogre's") {
+		t.Errorf("Dump without the cause of death")
+	}
+	if hs := g.HighScore(); !strings.HasPrefix(hs.KilledBy(), "an ogre's") {
+		t.Errorf("Bad high-score killer: %q", hs.KilledBy())
+	}
+}
+
+func TestHeadlessWin(t *testing.T) {
+	g, h := newHeadlessGame(5, ">")
+	g.Depth = g.MaxDepth()
+	g.Stairs[g.Player.Pos] = true
+	g.EventLoop()
+	if h.Outcome != Won {
+		t.Errorf("Bad outcome: %v", h.Outcome)
+	}
+}
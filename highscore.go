@@ -0,0 +1,135 @@
+package boohu
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Score returns the score of the game: the gold collected, plus 100 points
+// per depth reached and 10 points per monster killed. Winning adds 1000
+// points, and up to 1000 more for escaping in less than 10000 turns.
+func (g *Game) Score() int {
+	depth := g.Depth
+	if depth > g.MaxDepth() {
+		depth = g.MaxDepth()
+	}
+	score := g.Player.Gold + 100*depth + 10*g.Killed
+	if g.Won() {
+		score += 1000
+		if turns := g.Turn / 10; turns < 10000 {
+			score += (10000 - turns) / 10
+		}
+	}
+	return score
+}
+
+// HighScore is an entry of the high-score table.
+type HighScore struct {
+	Name    string
+	Score   int
+	Outcome outcome
+	Depth   int
+	Turns   int
+	Gold    int
+	Killed  int
+	Killer  string
+	Attack  attack
+	Seed    int64
+	Date    time.Time
+	Daily   string // date of the daily challenge, if any
+}
+
+// maxHighScores is the number of entries kept in the high-score table.
+const maxHighScores = 100
+
+func (g *Game) highScoreFile() (string, error) {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "highscores.gob"), nil
+}
+
+// HighScore returns the high-score entry of the game.
+func (g *Game) HighScore() HighScore {
+	return HighScore{
+		Name:    g.Name,
+		Score:   g.Score(),
+		Outcome: g.Outcome(),
+		Depth:   g.Depth,
+		Turns:   g.Turn / 10,
+		Gold:    g.Player.Gold,
+		Killed:  g.Killed,
+		Killer:  g.Killer,
+		Attack:  g.KilledBy.Attack,
+		Seed:    g.Seed,
+		Date:    time.Now(),
+		Daily:   g.Daily,
+	}
+}
+
+// KilledBy describes what killed the player, or returns an empty string if
+// it is not known.
+func (hs HighScore) KilledBy() string {
+	if hs.Killer == "" {
+		return ""
+	}
+	return killedBy(hs.Killer, hs.Attack)
+}
+
+// HighScores returns the high-score table, best score first.
+func (g *Game) HighScores() ([]HighScore, error) {
+	file, err := g.highScoreFile()
+	if err != nil {
+		return nil, err
+	}
+	return readHighScores(file)
+}
+
+func readHighScores(file string) ([]HighScore, error) {
+	scores := []HighScore{}
+	err := readGob(file, &scores)
+	if err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// WriteHighScore adds the finished game to the high-score table, and returns
+// its rank, starting from 1, or 0 if it did not enter the table. Games played
+// in wizard mode are not recorded.
+func (g *Game) WriteHighScore() (int, error) {
+	if g.noSave || g.Wizard {
+		return 0, nil
+	}
+	file, err := g.highScoreFile()
+	if err != nil {
+		return 0, err
+	}
+	return addHighScore(file, g.HighScore(), maxHighScores)
+}
+
+// addHighScore adds hs to the table of file keeping at most max entries, and
+// returns its rank, or 0 if it did not enter the table.
+func addHighScore(file string, hs HighScore, max int) (int, error) {
+	scores, err := readHighScores(file)
+	if err != nil {
+		return 0, err
+	}
+	rank := sort.Search(len(scores), func(i int) bool { return scores[i].Score < hs.Score })
+	if rank >= max {
+		return 0, nil
+	}
+	scores = append(scores, HighScore{})
+	copy(scores[rank+1:], scores[rank:])
+	scores[rank] = hs
+	if len(scores) > max {
+		scores = scores[:max]
+	}
+	err = writeGob(file, scores)
+	if err != nil {
+		return 0, err
+	}
+	return rank + 1, nil
+}
@@ -0,0 +1,46 @@
+package boohu
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	g, _ := newHeadlessGame(15, "")
+	g.Player.Gold = 50
+	g.Killed = 3
+	g.Depth = 4
+	if score := g.Score(); score != 50+400+30 {
+		t.Errorf("Score %d for an unfinished game", score)
+	}
+	g.Depth = g.MaxDepth() + 1
+	g.Turn = 40000
+	if score := g.Score(); score != 50+100*g.MaxDepth()+30+1000+600 {
+		t.Errorf("Score %d for a won game", score)
+	}
+	g.Turn = 200000
+	if score := g.Score(); score != 50+100*g.MaxDepth()+30+1000 {
+		t.Errorf("Score %d for a slow win", score)
+	}
+}
+
+func TestHighScores(t *testing.T) {
+	file := t.TempDir() + "/highscores.gob"
+	for i, score := range []int{20, 40, 10, 30} {
+		rank, err := addHighScore(file, HighScore{Score: score}, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []int{1, 1, 3, 2}[i]; rank != want {
+			t.Errorf("Score %d got rank %d instead of %d", score, rank, want)
+		}
+	}
+	rank, err := addHighScore(file, HighScore{Score: 5}, 3)
+	if err != nil || rank != 0 {
+		t.Errorf("Low score got rank %d (%v)", rank, err)
+	}
+	scores, err := readHighScores(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != 3 || scores[0].Score != 40 || scores[1].Score != 30 || scores[2].Score != 20 {
+		t.Errorf("Bad high scores: %+v", scores)
+	}
+}
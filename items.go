@@ -1,9 +1,9 @@
-package main
+package boohu
 
 import (
-	"container/heap"
 	"errors"
 	"fmt"
+	"sort"
 )
 
 // + consumables (potion-like or throwing dart, strategic + tactical)
@@ -13,7 +13,7 @@ import (
 //     fireball, lightning bolt, shatter, blink, teleport other
 
 type consumable interface {
-	Use(*game, event) error
+	Use(*Game, Event) error
 	String() string
 	Plural() string
 	Desc() string
@@ -21,8 +21,12 @@ type consumable interface {
 	Int() int
 }
 
-func (g *game) UseConsumable(c consumable) {
+func (g *Game) UseConsumable(c consumable) {
 	g.Player.Consumables[c]--
+	if g.Used == nil {
+		g.Used = map[consumable]int{}
+	}
+	g.Used[c]++
 	g.StoryPrintf("You used %s.", Indefinite(c.String(), false))
 	if g.Player.Consumables[c] <= 0 {
 		delete(g.Player.Consumables, c)
@@ -112,7 +116,7 @@ func (p potion) Int() int {
 	return int(p)
 }
 
-func (p potion) Use(g *game, ev event) error {
+func (p potion) Use(g *Game, ev Event) error {
 	quant, ok := g.Player.Consumables[p]
 	if !ok || quant <= 0 {
 		// should not happen
@@ -153,31 +157,31 @@ func (p potion) Use(g *game, ev event) error {
 	return nil
 }
 
-func (g *game) QuaffTeleportation(ev event) error {
+func (g *Game) QuaffTeleportation(ev Event) error {
 	if g.Player.HasStatus(StatusLignification) {
 		return errors.New("You cannot teleport while lignified.")
 	}
 	if g.Player.HasStatus(StatusTele) {
 		return errors.New("You already quaffed a potion of teleportation.")
 	}
-	delay := 20 + RandInt(30)
+	delay := 20 + g.Rand.Int(30)
 	g.Player.Statuses[StatusTele]++
-	heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + delay, EAction: Teleportation})
+	g.PushEvent(&simpleEvent{ERank: ev.Rank() + delay, EAction: Teleportation})
 	g.Printf("You quaff a %s. You feel unstable.", TeleportationPotion)
 	return nil
 }
 
-func (g *game) QuaffBerserk(ev event) error {
+func (g *Game) QuaffBerserk(ev Event) error {
 	if g.Player.HasStatus(StatusExhausted) {
 		return errors.New("You are too exhausted to berserk.")
 	}
 	g.Player.Statuses[StatusBerserk]++
-	heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 65 + RandInt(20), EAction: BerserkEnd})
+	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 65 + g.Rand.Int(20), EAction: BerserkEnd})
 	g.Printf("You quaff a %s. You feel a sudden urge to kill things.", BerserkPotion)
 	return nil
 }
 
-func (g *game) QuaffHealWounds(ev event) error {
+func (g *Game) QuaffHealWounds(ev Event) error {
 	hp := g.Player.HP
 	g.Player.HP += 2 * g.Player.HPMax() / 3
 	if g.Player.HP > g.Player.HPMax() {
@@ -187,7 +191,7 @@ func (g *game) QuaffHealWounds(ev event) error {
 	return nil
 }
 
-func (g *game) QuaffMagic(ev event) error {
+func (g *Game) QuaffMagic(ev Event) error {
 	mp := g.Player.MP
 	g.Player.MP += 2 * g.Player.MPMax() / 3
 	if g.Player.MP > g.Player.MPMax() {
@@ -197,7 +201,7 @@ func (g *game) QuaffMagic(ev event) error {
 	return nil
 }
 
-func (g *game) QuaffDescent(ev event) error {
+func (g *Game) QuaffDescent(ev Event) error {
 	if g.Player.HasStatus(StatusLignification) {
 		return errors.New("You cannot descend while lignified.")
 	}
@@ -211,28 +215,28 @@ func (g *game) QuaffDescent(ev event) error {
 	return nil
 }
 
-func (g *game) QuaffHaste(ev event) error {
+func (g *Game) QuaffHaste(ev Event) error {
 	g.Player.Statuses[StatusSwift]++
-	heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 80 + RandInt(20), EAction: HasteEnd})
+	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 80 + g.Rand.Int(20), EAction: HasteEnd})
 	g.Printf("You quaff the %s. You feel speedy.", RunningPotion)
 	return nil
 }
 
-func (g *game) QuaffEvasion(ev event) error {
+func (g *Game) QuaffEvasion(ev Event) error {
 	g.Player.Statuses[StatusAgile]++
-	heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 90 + RandInt(20), EAction: EvasionEnd})
+	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 90 + g.Rand.Int(20), EAction: EvasionEnd})
 	g.Printf("You quaff the %s. You feel agile.", EvasionPotion)
 	return nil
 }
 
-func (g *game) QuaffLignification(ev event) error {
+func (g *Game) QuaffLignification(ev Event) error {
 	g.Player.Statuses[StatusLignification]++
-	heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 150 + RandInt(100), EAction: LignificationEnd})
+	g.PushEvent(&simpleEvent{ERank: ev.Rank() + 150 + g.Rand.Int(100), EAction: LignificationEnd})
 	g.Printf("You quaff the %s. You feel attuned with the ground.", LignificationPotion)
 	return nil
 }
 
-func (g *game) QuaffMagicMapping(ev event) error {
+func (g *Game) QuaffMagicMapping(ev Event) error {
 	for i, c := range g.Dungeon.Cells {
 		pos := g.Dungeon.CellPosition(i)
 		if c.T == FreeCell || g.Dungeon.WallNeighborsCount(pos) < 8 {
@@ -297,13 +301,20 @@ func (p projectile) Int() int {
 	return int(p)
 }
 
-func (p projectile) Use(g *game, ev event) error {
+// Targetter returns the targetter for choosing the monster the projectile is
+// thrown at. The target is expected in g.Player.TargetID when the
+// projectile is used.
+func (p projectile) Targetter() Targetter {
+	return &chooser{single: true}
+}
+
+func (p projectile) Use(g *Game, ev Event) error {
 	quant, ok := g.Player.Consumables[p]
 	if !ok || quant <= 0 {
 		// should not happen
 		return errors.New("no such consumable: " + p.String())
 	}
-	mons, _ := g.MonsterAt(g.Player.Target)
+	mons := g.MonsterByID(g.Player.TargetID)
 	if mons == nil {
 		// should not happen
 		return errors.New("internal error: no monster")
@@ -318,9 +329,9 @@ func (p projectile) Use(g *game, ev event) error {
 	return nil
 }
 
-func (g *game) ThrowJavelin(mons *monster, ev event) {
-	acc := RandInt(g.Player.Accuracy())
-	evasion := RandInt(mons.Evasion)
+func (g *Game) ThrowJavelin(mons *Monster, ev Event) {
+	acc := g.Rand.Int(g.Player.Accuracy())
+	evasion := g.Rand.Int(mons.Evasion)
 	if mons.State == Resting {
 		evasion /= 2 + 1
 	}
@@ -328,7 +339,7 @@ func (g *game) ThrowJavelin(mons *monster, ev event) {
 		g.MakeNoise(12, mons.Pos)
 		bonus := 0
 		if g.Player.HasStatus(StatusBerserk) {
-			bonus += RandInt(5)
+			bonus += g.Rand.Int(5)
 		}
 		if g.Player.Aptitudes[AptStrong] {
 			bonus += 2
@@ -349,17 +360,17 @@ func (g *game) ThrowJavelin(mons *monster, ev event) {
 	ev.Renew(g, 10)
 }
 
-func (g *game) ThrowConfusingDart(mons *monster, ev event) {
-	acc := RandInt(g.Player.Accuracy())
-	evasion := RandInt(mons.Evasion)
+func (g *Game) ThrowConfusingDart(mons *Monster, ev Event) {
+	acc := g.Rand.Int(g.Player.Accuracy())
+	evasion := g.Rand.Int(mons.Evasion)
 	if mons.State == Resting {
 		evasion /= 2 + 1
 	}
 	if acc > evasion {
 		mons.Statuses[MonsConfused]++
 		mons.Path = nil
-		heap.Push(g.Events, &monsterEvent{
-			ERank: ev.Rank() + 50 + RandInt(100), NMons: mons.Index(g), EAction: MonsConfusionEnd})
+		g.PushEvent(&monsterEvent{
+			ERank: ev.Rank() + 50 + g.Rand.Int(100), MonsID: mons.ID, EAction: MonsConfusionEnd})
 		g.Printf("Your %s hits the %s. The %s appears confused.", ConfusingDart, mons.Kind, mons.Kind)
 	} else {
 		g.Printf("Your %s missed the %s.", ConfusingDart, mons.Kind)
@@ -392,8 +403,19 @@ var ConsumablesCollectData = map[consumable]collectData{
 	ConfusingDart:       {rarity: 5, quantity: 2},
 }
 
+// SortedCollectables returns the consumables that can be generated, in a
+// fixed order, so that item generation only depends on the game seed.
+func SortedCollectables() []consumable {
+	cs := []consumable{}
+	for c := range ConsumablesCollectData {
+		cs = append(cs, c)
+	}
+	sort.Slice(cs, func(i, j int) bool { return cs[i].String() < cs[j].String() })
+	return cs
+}
+
 type equipable interface {
-	Equip(g *game)
+	Equip(g *Game)
 	String() string
 	Letter() rune
 	Desc() string
@@ -408,7 +430,7 @@ const (
 	PlateArmour
 )
 
-func (ar armour) Equip(g *game) {
+func (ar armour) Equip(g *Game) {
 	oar := g.Player.Armour
 	g.Player.Armour = ar
 	if !g.FoundEquipables[ar] {
@@ -469,7 +491,7 @@ const (
 	DoubleSword
 )
 
-func (wp weapon) Equip(g *game) {
+func (wp weapon) Equip(g *Game) {
 	owp := g.Player.Weapon
 	g.Player.Weapon = wp
 	if !g.FoundEquipables[wp] {
@@ -578,7 +600,7 @@ const (
 	Shield
 )
 
-func (sh shield) Equip(g *game) {
+func (sh shield) Equip(g *Game) {
 	osh := g.Player.Shield
 	g.Player.Shield = sh
 	if !g.FoundEquipables[sh] {
@@ -636,14 +658,14 @@ type equipableData struct {
 	minDepth int
 }
 
-func (data equipableData) FavorableRoll(lateness int) int {
+func (data equipableData) FavorableRoll(rnd *rng, lateness int) int {
 	ratio := data.rarity / (2 * lateness)
 	if ratio < 2 {
 		ratio = 2
 	}
-	r := RandInt(ratio)
+	r := rnd.Int(ratio)
 	if r != 0 && ratio == 2 && lateness >= 3 {
-		r = RandInt(ratio)
+		r = rnd.Int(ratio)
 	}
 	return r
 }
@@ -663,3 +685,14 @@ var EquipablesRepartitionData = map[equipable]equipableData{
 	Buckler:       equipableData{10, 2},
 	Shield:        equipableData{15, 5},
 }
+
+// SortedEquipables returns the equipables that can be generated, in a fixed
+// order, so that equipment generation only depends on the game seed.
+func SortedEquipables() []equipable {
+	eqs := []equipable{}
+	for eq := range EquipablesRepartitionData {
+		eqs = append(eqs, eq)
+	}
+	sort.Slice(eqs, func(i, j int) bool { return eqs[i].String() < eqs[j].String() })
+	return eqs
+}
@@ -0,0 +1,169 @@
+package boohu
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// JSONDumpVersion is the version of the schema of JSON dumps. Fields may be
+// added without changing it, but it is increased whenever a field is
+// removed, renamed or changes meaning, so that scripts can check it.
+const JSONDumpVersion = 1
+
+// JSONDump is the machine-readable character dump. Outcome is one of "won",
+// "died", "abandoned" and "unfinished". Map has one string per row of the dungeon, with
+// the glyphs of the text dump and spaces for unexplored cells.
+type JSONDump struct {
+	SchemaVersion  int           `json:"schema_version"`
+	GameVersion    string        `json:"game_version"`
+	Name           string        `json:"name"`
+	Outcome        string        `json:"outcome"`
+	Wizard         bool          `json:"wizard"`
+	Daily          string        `json:"daily,omitempty"`
+	Depth          int           `json:"depth"`
+	Turns          float64       `json:"turns"`
+	Score          int           `json:"score"`
+	Seed           int64         `json:"seed"`
+	HP             int           `json:"hp"`
+	HPMax          int           `json:"hp_max"`
+	MP             int           `json:"mp"`
+	MPMax          int           `json:"mp_max"`
+	Gold           int           `json:"gold"`
+	Aptitudes      []string      `json:"aptitudes"`
+	Statuses       []JSONStatus  `json:"statuses"`
+	Equipment      JSONEquipment `json:"equipment"`
+	Rods           []JSONRod     `json:"rods"`
+	Potions        []JSONItem    `json:"potions"`
+	Projectiles    []JSONItem    `json:"projectiles"`
+	Killed         int           `json:"killed"`
+	KilledMonsters []JSONItem    `json:"killed_monsters"`
+	KilledBy       *JSONDamage   `json:"killed_by,omitempty"`
+	Damages        []JSONDamage  `json:"damages"`
+	Story          []string      `json:"story"`
+	LastMessages   []string      `json:"last_messages"`
+	Map            []string      `json:"map"`
+}
+
+type JSONStatus struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"` // times the status was gained and has not ended yet
+}
+
+type JSONEquipment struct {
+	Armour string `json:"armour"`
+	Weapon string `json:"weapon"`
+	Shield string `json:"shield,omitempty"`
+}
+
+type JSONRod struct {
+	Name       string `json:"name"`
+	Charges    int    `json:"charges"`
+	MaxCharges int    `json:"max_charges"`
+}
+
+// JSONDamage is a hit taken by the player. Source is the attacking monster,
+// and is empty for explosions.
+type JSONDamage struct {
+	Source string  `json:"source,omitempty"`
+	Attack string  `json:"attack"`
+	Damage int     `json:"damage"`
+	HP     int     `json:"hp"`
+	Depth  int     `json:"depth"`
+	Turn   float64 `json:"turn"`
+}
+
+func jsonDamage(d DamageEvent) JSONDamage {
+	return JSONDamage{Source: d.Source, Attack: d.Attack.String(), Damage: d.Damage, HP: d.HP, Depth: d.Depth, Turn: float64(d.Turn) / 10}
+}
+
+// JSONItem is a count of items, or of killed monsters.
+type JSONItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// JSONDump returns the machine-readable character dump.
+func (g *Game) JSONDump() JSONDump {
+	d := JSONDump{
+		SchemaVersion: JSONDumpVersion,
+		GameVersion:   Version,
+		Name:          g.Name,
+		Outcome:       g.Outcome().String(),
+		Wizard:        g.Wizard,
+		Daily:         g.Daily,
+		Depth:         g.Depth,
+		Turns:         float64(g.Turn) / 10,
+		Score:         g.Score(),
+		Seed:          g.Seed,
+		HP:            g.Player.HP,
+		HPMax:         g.Player.HPMax(),
+		MP:            g.Player.MP,
+		MPMax:         g.Player.MPMax(),
+		Gold:          g.Player.Gold,
+		Aptitudes:     []string{},
+		Statuses:      []JSONStatus{},
+		Equipment: JSONEquipment{
+			Armour: g.Player.Armour.String(),
+			Weapon: g.Player.Weapon.String(),
+		},
+		Rods:           []JSONRod{},
+		Potions:        []JSONItem{},
+		Projectiles:    []JSONItem{},
+		Killed:         g.Killed,
+		KilledMonsters: []JSONItem{},
+		Damages:        []JSONDamage{},
+		Story:          append([]string{}, g.Story...),
+		LastMessages:   []string{},
+	}
+	for apt, b := range g.Player.Aptitudes {
+		if b {
+			d.Aptitudes = append(d.Aptitudes, apt.String())
+		}
+	}
+	sort.Strings(d.Aptitudes)
+	for _, st := range g.SortedStatuses() {
+		d.Statuses = append(d.Statuses, JSONStatus{Name: st.String(), Count: g.Player.Statuses[st]})
+	}
+	if g.Player.Shield != NoShield {
+		d.Equipment.Shield = g.Player.Shield.String()
+	}
+	for _, r := range g.SortedRods() {
+		d.Rods = append(d.Rods, JSONRod{Name: r.String(), Charges: g.Player.Rods[r].Charge, MaxCharges: r.MaxCharge()})
+	}
+	for _, p := range g.SortedPotions() {
+		d.Potions = append(d.Potions, JSONItem{Name: p.String(), Count: g.Player.Consumables[p]})
+	}
+	for _, p := range g.SortedProjectiles() {
+		d.Projectiles = append(d.Projectiles, JSONItem{Name: p.String(), Count: g.Player.Consumables[p]})
+	}
+	for _, mk := range g.SortedKilledMonsters() {
+		d.KilledMonsters = append(d.KilledMonsters, JSONItem{Name: mk.String(), Count: g.KilledMons[mk]})
+	}
+	if g.Player.HP <= 0 && g.KilledBy.Attack != NoAttack {
+		killedBy := jsonDamage(g.KilledBy)
+		d.KilledBy = &killedBy
+	}
+	for _, dmg := range g.Damages {
+		d.Damages = append(d.Damages, jsonDamage(dmg))
+	}
+	for i := len(g.Log) - 10; i < len(g.Log); i++ {
+		if i >= 0 {
+			d.LastMessages = append(d.LastMessages, g.Log[i])
+		}
+	}
+	for _, row := range strings.Split(strings.TrimSuffix(g.DumpDungeon(), "\n"), "\n") {
+		d.Map = append(d.Map, strings.TrimSuffix(strings.TrimPrefix(row, "│"), "│"))
+	}
+	return d
+}
+
+// MarshalJSONDump returns the JSON encoding of the machine-readable
+// character dump.
+func (g *Game) MarshalJSONDump() ([]byte, error) {
+	data, err := json.MarshalIndent(g.JSONDump(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
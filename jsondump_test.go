@@ -0,0 +1,41 @@
+package boohu
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONDump(t *testing.T) {
+	g, _ := newHeadlessGame(14, "")
+	g.Player.Statuses[StatusSlow] = 2
+	data, err := g.MarshalJSONDump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := JSONDump{}
+	err = json.Unmarshal(data, &d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.SchemaVersion != JSONDumpVersion || d.Outcome != "unfinished" || d.HP != g.Player.HP {
+		t.Errorf("Bad JSON dump: %+v", d)
+	}
+	if len(d.Map) != g.Dungeon.Heigth {
+		t.Fatalf("Got %d map rows instead of %d", len(d.Map), g.Dungeon.Heigth)
+	}
+	for i, row := range d.Map {
+		if n := len([]rune(row)); n != g.Dungeon.Width {
+			t.Errorf("Map row %d has %d cells instead of %d", i, n, g.Dungeon.Width)
+		}
+	}
+	if len(d.Potions) == 0 || len(d.Projectiles) == 0 {
+		t.Errorf("Missing starting items: %+v %+v", d.Potions, d.Projectiles)
+	}
+	if len(d.Statuses) != 1 || d.Statuses[0].Count != 2 {
+		t.Errorf("Bad statuses: %+v", d.Statuses)
+	}
+	g.Abandoned = true
+	if d := g.JSONDump(); d.Outcome != "abandoned" {
+		t.Errorf("Outcome %q for an abandoned game", d.Outcome)
+	}
+}
@@ -1,25 +1,25 @@
-package main
+package boohu
 
 import "fmt"
 
-func (g *game) Print(s string) {
+func (g *Game) Print(s string) {
 	g.Log = append(g.Log, s)
 	if len(g.Log) > 1000 {
 		g.Log = g.Log[500:]
 	}
 }
 
-func (g *game) Printf(format string, a ...interface{}) {
+func (g *Game) Printf(format string, a ...interface{}) {
 	g.Log = append(g.Log, fmt.Sprintf(format, a...))
 	if len(g.Log) > 1000 {
 		g.Log = g.Log[500:]
 	}
 }
 
-func (g *game) StoryPrint(s string) {
+func (g *Game) StoryPrint(s string) {
 	g.Story = append(g.Story, fmt.Sprintf("Depth %2d|Turn %7.1f| %s", g.Depth, float64(g.Turn)/10, s))
 }
 
-func (g *game) StoryPrintf(format string, a ...interface{}) {
+func (g *Game) StoryPrintf(format string, a ...interface{}) {
 	g.Story = append(g.Story, fmt.Sprintf("Depth %2d|Turn %7.1f| %s", g.Depth, float64(g.Turn)/10, fmt.Sprintf(format, a...)))
 }
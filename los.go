@@ -1,12 +1,12 @@
-package main
+package boohu
 
 type raynode struct {
 	Cost int
 }
 
-type rayMap map[position]*raynode
+type rayMap map[Position]*raynode
 
-func (rm rayMap) get(p position) *raynode {
+func (rm rayMap) get(p Position) *raynode {
 	r, ok := rm[p]
 	if !ok {
 		r = &raynode{}
@@ -15,7 +15,7 @@ func (rm rayMap) get(p position) *raynode {
 	return r
 }
 
-func (g *game) bestParent(rm rayMap, from, pos position) (position, int) {
+func (g *Game) bestParent(rm rayMap, from, pos Position) (Position, int) {
 	p := pos.Parents(from)
 	b := p[0]
 	if len(p) > 1 && rm[p[1]].Cost+g.losCost(p[1]) < rm[b].Cost+g.losCost(b) {
@@ -24,7 +24,7 @@ func (g *game) bestParent(rm rayMap, from, pos position) (position, int) {
 	return b, rm[b].Cost + g.losCost(b)
 }
 
-func (g *game) losCost(pos position) int {
+func (g *Game) losCost(pos Position) int {
 	cost := 1
 	c := g.Dungeon.Cell(pos)
 	if c.T == WallCell {
@@ -36,13 +36,13 @@ func (g *game) losCost(pos position) int {
 	return cost
 }
 
-func (g *game) buildRayMap(from position, distance int) rayMap {
+func (g *Game) buildRayMap(from Position, distance int) rayMap {
 	dungeon := g.Dungeon
 	rm := rayMap{}
 	rm[from] = &raynode{Cost: 0}
 	for d := 1; d <= distance; d++ {
 		for x := -d + from.X; x <= d+from.X; x++ {
-			for _, pos := range []position{{x, from.Y + d}, {x, from.Y - d}} {
+			for _, pos := range []Position{{x, from.Y + d}, {x, from.Y - d}} {
 				if !dungeon.Valid(pos) {
 					continue
 				}
@@ -51,7 +51,7 @@ func (g *game) buildRayMap(from position, distance int) rayMap {
 			}
 		}
 		for y := -d + 1 + from.Y; y <= d-1+from.Y; y++ {
-			for _, pos := range []position{{from.X + d, y}, {from.X - d, y}} {
+			for _, pos := range []Position{{from.X + d, y}, {from.X - d, y}} {
 				if !dungeon.Valid(pos) {
 					continue
 				}
@@ -63,7 +63,7 @@ func (g *game) buildRayMap(from position, distance int) rayMap {
 	return rm
 }
 
-func (g *game) LosRange() int {
+func (g *Game) LosRange() int {
 	losRange := 6
 	if g.Player.Aptitudes[AptStealthyLOS] {
 		losRange -= 1
@@ -71,8 +71,8 @@ func (g *game) LosRange() int {
 	return losRange
 }
 
-func (g *game) ComputeLOS() {
-	m := map[position]bool{}
+func (g *Game) ComputeLOS() {
+	m := map[Position]bool{}
 	losRange := g.LosRange()
 	g.Player.Rays = g.buildRayMap(g.Player.Pos, losRange)
 	for pos, n := range g.Player.Rays {
@@ -107,7 +107,7 @@ func (g *game) ComputeLOS() {
 	g.Player.LOS = m
 }
 
-func (g *game) ComputeExclusion(pos position, toggle bool) {
+func (g *Game) ComputeExclusion(pos Position, toggle bool) {
 	exclusionRange := g.LosRange()
 	rays := g.buildRayMap(pos, exclusionRange)
 	for pos, n := range rays {
@@ -117,7 +117,7 @@ func (g *game) ComputeExclusion(pos position, toggle bool) {
 	}
 	for d := 1; d <= exclusionRange; d++ {
 		for x := -d + pos.X; x <= d+pos.X; x++ {
-			for _, pos := range []position{{x, pos.Y + d}, {x, pos.Y - d}} {
+			for _, pos := range []Position{{x, pos.Y + d}, {x, pos.Y - d}} {
 				if !g.Dungeon.Valid(pos) {
 					continue
 				}
@@ -127,7 +127,7 @@ func (g *game) ComputeExclusion(pos position, toggle bool) {
 			}
 		}
 		for y := -d + 1 + pos.Y; y <= d-1+pos.Y; y++ {
-			for _, pos := range []position{{pos.X + d, y}, {pos.X - d, y}} {
+			for _, pos := range []Position{{pos.X + d, y}, {pos.X - d, y}} {
 				if !g.Dungeon.Valid(pos) {
 					continue
 				}
@@ -139,11 +139,11 @@ func (g *game) ComputeExclusion(pos position, toggle bool) {
 	}
 }
 
-func (g *game) Ray(pos position) []position {
+func (g *Game) Ray(pos Position) []Position {
 	if !g.Player.LOS[pos] {
 		return nil
 	}
-	ray := []position{}
+	ray := []Position{}
 	for pos != g.Player.Pos {
 		ray = append(ray, pos)
 		pos, _ = g.bestParent(g.Player.Rays, g.Player.Pos, pos)
@@ -151,8 +151,8 @@ func (g *game) Ray(pos position) []position {
 	return ray
 }
 
-func (g *game) ComputeRayHighlight(pos position) {
-	g.Highlight = map[position]bool{}
+func (g *Game) ComputeRayHighlight(pos Position) {
+	g.Highlight = map[Position]bool{}
 	ray := g.Ray(pos)
 	for _, p := range ray {
 		g.Highlight[p] = true
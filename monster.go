@@ -1,6 +1,8 @@
-package main
+package boohu
 
-import "container/heap"
+import (
+	"sort"
+)
 
 type monsterState int
 
@@ -220,22 +222,34 @@ type monsterBandData struct {
 	unique       bool
 }
 
-func (g *game) GenBand(mbd monsterBandData, band monsterBand) []monsterKind {
+func (g *Game) GenBand(mbd monsterBandData, band monsterBand) []monsterKind {
 	if g.GeneratedBands[band] > 0 && mbd.unique {
 		return nil
 	}
-	if g.Depth > mbd.maxDepth+RandInt(3) || RandInt(10) == 0 {
+	if g.Depth > mbd.maxDepth+g.Rand.Int(3) || g.Rand.Int(10) == 0 {
 		return nil
 	}
-	if g.Depth < mbd.minDepth-RandInt(3) {
+	if g.Depth < mbd.minDepth-g.Rand.Int(3) {
 		return nil
 	}
+	return g.bandMonsters(mbd)
+}
+
+// bandMonsters returns the monsters of a new band.
+func (g *Game) bandMonsters(mbd monsterBandData) []monsterKind {
 	if !mbd.band {
 		return []monsterKind{mbd.monster}
 	}
+	kinds := []monsterKind{}
+	for m := range mbd.distribution {
+		kinds = append(kinds, m)
+	}
+	// sort for the band to depend only on the game seed
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
 	bandMonsters := []monsterKind{}
-	for m, interval := range mbd.distribution {
-		for i := 0; i < interval.min+RandInt(interval.max-interval.min+1); i++ {
+	for _, m := range kinds {
+		interval := mbd.distribution[m]
+		for i := 0; i < interval.min+g.Rand.Int(interval.max-interval.min+1); i++ {
 			bandMonsters = append(bandMonsters, m)
 		}
 	}
@@ -365,7 +379,8 @@ var MonsBands = []monsterBandData{
 	},
 }
 
-type monster struct {
+type Monster struct {
+	ID          int
 	Kind        monsterKind
 	Band        int
 	Attack      int
@@ -374,16 +389,16 @@ type monster struct {
 	Evasion     int
 	HPmax       int
 	HP          int
-	Pos         position
+	Pos         Position
 	State       monsterState
 	Statuses    map[monsterStatus]int
-	Target      position
-	Path        []position // cache
+	Target      Position
+	Path        []Position // cache
 	Obstructing bool
 }
 
-func (m *monster) Init() {
-	m.HPmax = MonsData[m.Kind].maxHP - 1 + RandInt(3)
+func (m *Monster) Init(g *Game) {
+	m.HPmax = MonsData[m.Kind].maxHP - 1 + g.Rand.Int(3)
 	m.Attack = MonsData[m.Kind].baseAttack
 	m.HP = m.HPmax
 	m.Accuracy = MonsData[m.Kind].accuracy
@@ -392,16 +407,16 @@ func (m *monster) Init() {
 	m.Statuses = map[monsterStatus]int{}
 }
 
-func (m *monster) Status(st monsterStatus) bool {
+func (m *Monster) Status(st monsterStatus) bool {
 	return m.Statuses[st] > 0
 }
 
-func (m *monster) Exists() bool {
+func (m *Monster) Exists() bool {
 	return m != nil && m.HP > 0
 }
 
-func (m *monster) AlternatePlacement(g *game) *position {
-	var neighbors []position
+func (m *Monster) AlternatePlacement(g *Game) *Position {
+	var neighbors []Position
 	if m.Status(MonsConfused) {
 		neighbors = g.Dungeon.CardinalFreeNeighbors(m.Pos)
 	} else {
@@ -411,7 +426,7 @@ func (m *monster) AlternatePlacement(g *game) *position {
 		if pos.Distance(g.Player.Pos) != 1 {
 			continue
 		}
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons.Exists() {
 			continue
 		}
@@ -420,7 +435,7 @@ func (m *monster) AlternatePlacement(g *game) *position {
 	return nil
 }
 
-func (m *monster) AttackAction(g *game, ev event) {
+func (m *Monster) AttackAction(g *Game, ev Event) {
 	switch {
 	case m.Obstructing:
 		m.Obstructing = false
@@ -443,12 +458,12 @@ func (m *monster) AttackAction(g *game, ev event) {
 	}
 }
 
-func (m *monster) HandleTurn(g *game, ev event) {
+func (m *Monster) HandleTurn(g *Game, ev Event) {
 	ppos := g.Player.Pos
 	mpos := m.Pos
 	m.MakeAware(g)
 	if m.State == Resting {
-		wander := RandInt(1500)
+		wander := g.Rand.Int(1500)
 		if wander == 0 {
 			m.Target = g.FreeCell()
 			m.State = Wandering
@@ -484,7 +499,7 @@ func (m *monster) HandleTurn(g *game, ev event) {
 	if m.Path == nil || len(m.Path) < 2 {
 		switch m.State {
 		case Wandering:
-			keepWandering := RandInt(100)
+			keepWandering := g.Rand.Int(100)
 			if keepWandering > 75 && MonsBands[g.Bands[m.Band]].band {
 				for _, mons := range g.Monsters {
 					m.Target = mons.Pos
@@ -494,7 +509,7 @@ func (m *monster) HandleTurn(g *game, ev event) {
 			}
 			m.GatherBand(g)
 		case Hunting:
-			if RandInt(5) == 0 && m.Pos.Distance(g.Player.Pos) < 10 {
+			if g.Rand.Int(5) == 0 && m.Pos.Distance(g.Player.Pos) < 10 {
 				// make hunting monsters sometimes smart
 				m.Target = g.Player.Pos
 			} else {
@@ -507,7 +522,7 @@ func (m *monster) HandleTurn(g *game, ev event) {
 		return
 	}
 	target := m.Path[len(m.Path)-2]
-	mons, _ := g.MonsterAt(target)
+	mons := g.MonsterAt(target)
 	switch {
 	case !mons.Exists():
 		m.Pos = m.Path[len(m.Path)-2]
@@ -525,7 +540,7 @@ func (m *monster) HandleTurn(g *game, ev event) {
 		}
 		m.Path = m.Path[:len(m.Path)-1]
 	case !g.Player.LOS[mons.Pos] && g.Player.Pos.Distance(mons.Target) > 2 && mons.State != Hunting:
-		r := RandInt(10)
+		r := g.Rand.Int(10)
 		if r == 0 {
 			m.Target = g.FreeCell()
 			m.GatherBand(g)
@@ -545,13 +560,13 @@ func (m *monster) HandleTurn(g *game, ev event) {
 	ev.Renew(g, m.Kind.MovementDelay())
 }
 
-func (m *monster) HitPlayer(g *game, ev event) {
+func (m *Monster) HitPlayer(g *Game, ev Event) {
 	if g.Player.HP <= 0 {
 		// for hydras
 		return
 	}
-	evasion := RandInt(g.Player.Evasion())
-	acc := RandInt(m.Accuracy)
+	evasion := g.Rand.Int(g.Player.Evasion())
+	acc := g.Rand.Int(m.Accuracy)
 	if acc > evasion {
 		if m.Blocked(g) {
 			g.Printf("You block the %s's attack with your %s.", m.Kind, g.Player.Shield)
@@ -563,45 +578,45 @@ func (m *monster) HitPlayer(g *game, ev event) {
 		attack := g.HitDamage(m.Attack, g.Player.Armor())
 		g.Printf("The %s hits you (%d damage).", m.Kind, attack)
 		m.HitSideEffects(g, ev)
-		m.InflictDamage(g, attack, m.Attack)
+		m.InflictDamage(g, MeleeAttack, attack, m.Attack)
 	} else {
 		g.Printf("The %s misses you.", m.Kind)
 	}
 }
 
-func (m *monster) HitSideEffects(g *game, ev event) {
+func (m *Monster) HitSideEffects(g *Game, ev Event) {
 	switch m.Kind {
 	case MonsSpider:
-		if RandInt(2) == 0 && !g.Player.HasStatus(StatusConfusion) {
+		if g.Rand.Int(2) == 0 && !g.Player.HasStatus(StatusConfusion) {
 			g.Player.Statuses[StatusConfusion]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: ConfusionEnd})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 100 + g.Rand.Int(100), EAction: ConfusionEnd})
 			g.Print("You feel confused.")
 		}
 	case MonsGiantBee:
-		if RandInt(5) == 0 && !g.Player.HasStatus(StatusBerserk) {
+		if g.Rand.Int(5) == 0 && !g.Player.HasStatus(StatusBerserk) {
 			g.Player.Statuses[StatusBerserk]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 25 + RandInt(40), EAction: BerserkEnd})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 25 + g.Rand.Int(40), EAction: BerserkEnd})
 			g.Print("You feel a sudden urge to kill things.")
 		}
 	case MonsBlinkingFrog:
-		if RandInt(2) == 0 {
+		if g.Rand.Int(2) == 0 {
 			g.Blink(ev)
 		}
 	case MonsBrizzia:
-		if RandInt(3) == 0 && !g.Player.HasStatus(StatusNausea) {
+		if g.Rand.Int(3) == 0 && !g.Player.HasStatus(StatusNausea) {
 			g.Player.Statuses[StatusNausea]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 30 + RandInt(20), EAction: NauseaEnd})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 30 + g.Rand.Int(20), EAction: NauseaEnd})
 			g.Print("You feel sick.")
 		}
 	case MonsAcidMound:
 		g.Player.Statuses[StatusCorrosion]++
-		heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 80 + RandInt(40), EAction: CorrosionEnd})
+		g.PushEvent(&simpleEvent{ERank: ev.Rank() + 80 + g.Rand.Int(40), EAction: CorrosionEnd})
 		g.Print("Your equipment is corroded..")
 	}
 
 }
 
-func (m *monster) RangedAttack(g *game, ev event) bool {
+func (m *Monster) RangedAttack(g *Game, ev Event) bool {
 	if !m.Kind.Ranged() {
 		return false
 	}
@@ -626,11 +641,11 @@ func (m *monster) RangedAttack(g *game, ev event) bool {
 	return false
 }
 
-func (m *monster) RangeBlocked(g *game) bool {
+func (m *Monster) RangeBlocked(g *Game) bool {
 	ray := g.Ray(m.Pos)
 	blocked := false
 	for _, pos := range ray[1:] {
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons == nil {
 			continue
 		}
@@ -640,44 +655,34 @@ func (m *monster) RangeBlocked(g *game) bool {
 	return blocked
 }
 
-func (m *monster) Index(g *game) int {
-	for i, mons := range g.Monsters {
-		if mons.Pos == m.Pos {
-			return i
-		}
-	}
-	// not reached
-	return 0
-}
-
-func (m *monster) TormentBolt(g *game, ev event) bool {
+func (m *Monster) TormentBolt(g *Game, ev Event) bool {
 	blocked := m.RangeBlocked(g)
 	if blocked {
 		return false
 	}
 	//g.Player.Statuses[StatusSlow]++
-	//heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 50 + RandInt(50), EAction: SlowEnd})
+	//g.PushEvent(&simpleEvent{ERank: ev.Rank() + 50 + g.Rand.Int(50), EAction: SlowEnd})
 	hit := !m.Blocked(g)
 	g.MakeNoise(9, m.Pos)
 	if hit {
 		g.MakeNoise(12, g.Player.Pos)
 		damage := g.Player.HP - g.Player.HP/2
 		g.Printf("The %s throws a bolt of torment at you.", m.Kind)
-		m.InflictDamage(g, damage, 15)
+		m.InflictDamage(g, TormentBoltAttack, damage, 15)
 	} else {
 		g.Printf("You block the %s's bolt of torment.", m.Kind)
 	}
 	m.Statuses[MonsExhausted]++
-	heap.Push(g.Events, &monsterEvent{ERank: ev.Rank() + 100 + RandInt(50), NMons: m.Index(g), EAction: MonsExhaustionEnd})
+	g.PushEvent(&monsterEvent{ERank: ev.Rank() + 100 + g.Rand.Int(50), MonsID: m.ID, EAction: MonsExhaustionEnd})
 	ev.Renew(g, m.Kind.AttackDelay())
 	return true
 }
 
-func (m *monster) Blocked(g *game) bool {
+func (m *Monster) Blocked(g *Game) bool {
 	blocked := false
 	if g.Player.Shield != NoShield && !g.Player.Weapon.TwoHanded() {
-		block := RandInt(g.Player.Block())
-		acc := RandInt(m.Accuracy)
+		block := g.Rand.Int(g.Player.Block())
+		acc := g.Rand.Int(m.Accuracy)
 		if block >= acc {
 			g.MakeNoise(12+g.Player.Block()/2, g.Player.Pos)
 			blocked = true
@@ -686,15 +691,15 @@ func (m *monster) Blocked(g *game) bool {
 	return blocked
 }
 
-func (m *monster) ThrowRock(g *game, ev event) bool {
+func (m *Monster) ThrowRock(g *Game, ev Event) bool {
 	blocked := m.RangeBlocked(g)
 	if blocked {
 		return false
 	}
 	block := false
 	hit := true
-	evasion := RandInt(g.Player.Evasion())
-	acc := RandInt(m.Accuracy)
+	evasion := g.Rand.Int(g.Player.Evasion())
+	acc := g.Rand.Int(m.Accuracy)
 	if 3*acc/2 <= evasion {
 		// rocks are big and do not miss so often
 		hit = false
@@ -708,12 +713,12 @@ func (m *monster) ThrowRock(g *game, ev event) bool {
 		g.MakeNoise(noise, g.Player.Pos)
 		attack := g.HitDamage(15, g.Player.Armor())
 		g.Printf("The %s throws a rock at you (%d damage).", m.Kind, attack)
-		if RandInt(4) == 0 {
+		if g.Rand.Int(4) == 0 {
 			g.Player.Statuses[StatusConfusion]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: ConfusionEnd})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 100 + g.Rand.Int(100), EAction: ConfusionEnd})
 			g.Print("You feel confused.")
 		}
-		m.InflictDamage(g, attack, 15)
+		m.InflictDamage(g, RockAttack, attack, 15)
 	} else if block {
 		g.Printf("You block %s's rock.", Indefinite(m.Kind.String(), false))
 	} else {
@@ -723,15 +728,15 @@ func (m *monster) ThrowRock(g *game, ev event) bool {
 	return true
 }
 
-func (m *monster) ThrowJavelin(g *game, ev event) bool {
+func (m *Monster) ThrowJavelin(g *Game, ev Event) bool {
 	blocked := m.RangeBlocked(g)
 	if blocked {
 		return false
 	}
 	block := false
 	hit := true
-	evasion := RandInt(g.Player.Evasion())
-	acc := RandInt(m.Accuracy)
+	evasion := g.Rand.Int(g.Player.Evasion())
+	acc := g.Rand.Int(m.Accuracy)
 	if acc <= evasion {
 		hit = false
 	} else {
@@ -744,25 +749,25 @@ func (m *monster) ThrowJavelin(g *game, ev event) bool {
 		g.MakeNoise(noise, g.Player.Pos)
 		attack := g.HitDamage(11, g.Player.Armor())
 		g.Printf("The %s throws %s at you (%d damage).", m.Kind, Indefinite(Javelin.String(), false), attack)
-		m.InflictDamage(g, attack, 11)
+		m.InflictDamage(g, JavelinAttack, attack, 11)
 	} else if block {
-		if RandInt(3) == 0 {
+		if g.Rand.Int(3) == 0 {
 			g.Printf("You block %s's %s.", Indefinite(m.Kind.String(), false), Javelin)
 		} else {
 			g.Player.Statuses[StatusDisabledShield]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: DisabledShieldEnd})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 100 + g.Rand.Int(100), EAction: DisabledShieldEnd})
 			g.Printf("%s's %s gets fixed on your shield.", Indefinite(m.Kind.String(), true), Javelin)
 		}
 	} else {
 		g.Printf("You dodge %s's %s.", Indefinite(m.Kind.String(), false), Javelin)
 	}
 	m.Statuses[MonsExhausted]++
-	heap.Push(g.Events, &monsterEvent{ERank: ev.Rank() + 50 + RandInt(50), NMons: m.Index(g), EAction: MonsExhaustionEnd})
+	g.PushEvent(&monsterEvent{ERank: ev.Rank() + 50 + g.Rand.Int(50), MonsID: m.ID, EAction: MonsExhaustionEnd})
 	ev.Renew(g, m.Kind.AttackDelay())
 	return true
 }
 
-func (m *monster) SmitingAttack(g *game, ev event) bool {
+func (m *Monster) SmitingAttack(g *Game, ev Event) bool {
 	if !m.Kind.Smiting() {
 		return false
 	}
@@ -783,19 +788,19 @@ func (m *monster) SmitingAttack(g *game, ev event) bool {
 	return false
 }
 
-func (m *monster) AbsorbMana(g *game, ev event) bool {
+func (m *Monster) AbsorbMana(g *Game, ev Event) bool {
 	if g.Player.MP == 0 {
 		return false
 	}
 	g.Player.MP = 2 * g.Player.MP / 3
 	g.Printf("The %s absorbs your mana.", m.Kind)
 	m.Statuses[MonsExhausted]++
-	heap.Push(g.Events, &monsterEvent{ERank: ev.Rank() + 10 + RandInt(20), NMons: m.Index(g), EAction: MonsExhaustionEnd})
+	g.PushEvent(&monsterEvent{ERank: ev.Rank() + 10 + g.Rand.Int(20), MonsID: m.ID, EAction: MonsExhaustionEnd})
 	ev.Renew(g, m.Kind.AttackDelay())
 	return true
 }
 
-func (m *monster) MakeHuntIfHurt(g *game) {
+func (m *Monster) MakeHuntIfHurt(g *Game) {
 	if m.State != Hunting {
 		m.Target = g.Player.Pos
 		m.State = Hunting
@@ -809,14 +814,14 @@ func (m *monster) MakeHuntIfHurt(g *game) {
 	}
 }
 
-func (m *monster) MakeAware(g *game) {
+func (m *Monster) MakeAware(g *Game) {
 	if !g.Player.LOS[m.Pos] {
 		return
 	}
 	if m.State == Resting {
 		adjust := (m.Pos.Distance(g.Player.Pos) - g.LosRange()/2 + 1)
 		adjust *= adjust
-		r := RandInt(25 + 3*adjust)
+		r := g.Rand.Int(25 + 3*adjust)
 		if g.Player.Aptitudes[AptStealthyMovement] {
 			r *= 2
 		}
@@ -827,7 +832,7 @@ func (m *monster) MakeAware(g *game) {
 	if m.State == Wandering {
 		adjust := (m.Pos.Distance(g.Player.Pos) - g.LosRange()/2 + 1)
 		adjust *= adjust
-		r := RandInt(30 + adjust)
+		r := g.Rand.Int(30 + adjust)
 		if r >= 25 {
 			return
 		}
@@ -846,26 +851,26 @@ func (m *monster) MakeAware(g *game) {
 	m.State = Hunting
 }
 
-func (m *monster) Heal(g *game, ev event) {
+func (m *Monster) Heal(g *Game, ev Event) {
 	if m.HP < m.HPmax {
 		m.HP++
 	}
 	ev.Renew(g, 50)
 }
 
-func (m *monster) GatherBand(g *game) {
+func (m *Monster) GatherBand(g *Game) {
 	if !MonsBands[g.Bands[m.Band]].band {
 		return
 	}
 	dij := &normalPath{game: g}
-	nm := Dijkstra(dij, []position{m.Pos}, 4)
+	nm := Dijkstra(dij, []Position{m.Pos}, 4)
 	for _, mons := range g.Monsters {
 		if mons.Band == m.Band {
 			n, ok := nm[mons.Pos]
 			if !ok || n.Cost > 4 {
 				continue
 			}
-			r := RandInt(100)
+			r := g.Rand.Int(100)
 			if r > 60 || mons.State == Wandering && r > 10 {
 				mons.Target = m.Target
 				mons.State = m.State
@@ -875,32 +880,58 @@ func (m *monster) GatherBand(g *game) {
 	}
 }
 
-func (g *game) MonsterAt(pos position) (*monster, int) {
-	var mons *monster
-	var index int
-	for i, m := range g.Monsters {
+func (g *Game) MonsterAt(pos Position) *Monster {
+	var mons *Monster
+	for _, m := range g.Monsters {
 		if m.Pos == pos && m.HP > 0 {
 			mons = m
-			index = i
 			break
 		}
 	}
-	return mons, index
+	return mons
+}
+
+// AddMonster adds a monster to the current level, giving it a new identifier
+// unique for the whole game.
+func (g *Game) AddMonster(m *Monster) {
+	g.LastMonsterID++
+	m.ID = g.LastMonsterID
+	g.Monsters = append(g.Monsters, m)
+	if g.monstersByID == nil {
+		g.monstersByID = map[int]*Monster{}
+	}
+	g.monstersByID[m.ID] = m
+}
+
+// MonsterByID returns the monster of the current level with the given
+// identifier, or nil if there is no such monster.
+func (g *Game) MonsterByID(id int) *Monster {
+	return g.monstersByID[id]
+}
+
+// IndexMonsters rebuilds the identifier index of the current level's
+// monsters, which is not saved.
+func (g *Game) IndexMonsters() {
+	g.monstersByID = make(map[int]*Monster, len(g.Monsters))
+	for _, m := range g.Monsters {
+		g.monstersByID[m.ID] = m
+	}
 }
 
-func (g *game) GenMonsters() {
-	g.Monsters = []*monster{}
+func (g *Game) GenMonsters() {
+	g.Monsters = []*Monster{}
+	g.monstersByID = map[int]*Monster{}
 	g.Bands = []monsterBand{}
 	danger := 20 + 10*g.Depth + g.Depth*g.Depth/3
 	nmons := 15 + 3*g.Depth
-	nmons += RandInt(3)
+	nmons += g.Rand.Int(3)
 	if nmons > 40 {
-		nmons = 40 + RandInt(5)
+		nmons = 40 + g.Rand.Int(5)
 	}
 	nband := 0
 	for danger > 0 && nmons > 0 {
 		for band, data := range MonsBands {
-			if RandInt(data.rarity*2) != 0 {
+			if g.Rand.Int(data.rarity*2) != 0 {
 				continue
 			}
 			monsters := g.GenBand(data, monsterBand(band))
@@ -916,11 +947,11 @@ func (g *game) GenMonsters() {
 				if danger <= 0 || nmons <= 0 {
 					return
 				}
-				mons := &monster{Kind: mk}
-				mons.Init()
+				mons := &Monster{Kind: mk}
+				mons.Init(g)
 				mons.Pos = pos
 				mons.Band = nband
-				g.Monsters = append(g.Monsters, mons)
+				g.AddMonster(mons)
 				pos = g.FreeCellForBandMonster(pos)
 			}
 			nband++
@@ -928,7 +959,7 @@ func (g *game) GenMonsters() {
 	}
 }
 
-func (g *game) MonsterInLOS() *monster {
+func (g *Game) MonsterInLOS() *Monster {
 	for _, mons := range g.Monsters {
 		if mons.Exists() && g.Player.LOS[mons.Pos] {
 			return mons
@@ -0,0 +1,117 @@
+package boohu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// morgueTimeLayout is the layout of the time in morgue file names.
+const morgueTimeLayout = "2006-01-02-150405"
+
+// MorgueEntry describes the dump of a finished game kept in the morgue.
+type MorgueEntry struct {
+	File    string
+	Name    string
+	Time    time.Time
+	Outcome string
+}
+
+// MorgueDir returns the directory where the dumps of finished games are
+// kept.
+func (g *Game) MorgueDir() (string, error) {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(dataDir, "morgue")
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// WriteMorgue archives the dumps of a finished game in their own files of the
+// morgue, named after the character, the time and the outcome of the game:
+// a text file and a JSON file.
+func (g *Game) WriteMorgue() error {
+	if g.noSave {
+		return nil
+	}
+	dir, err := g.MorgueDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing dump: %s", err)
+		return err
+	}
+	return g.writeMorgue(dir, time.Now())
+}
+
+func (g *Game) writeMorgue(dir string, t time.Time) error {
+	file := "morgue"
+	if g.Name != "" {
+		file += "-" + fileName(g.Name)
+	}
+	file += fmt.Sprintf("-%s-%s", t.Format(morgueTimeLayout), g.Outcome())
+	file = filepath.Join(dir, file)
+	err := g.writeDumpFiles(file+".txt", file+".json")
+	if err != nil {
+		return err
+	}
+	g.morgueFile = file + ".txt"
+	return nil
+}
+
+// MorgueFile returns the text file written by the last WriteMorgue, if any.
+func (g *Game) MorgueFile() string {
+	return g.morgueFile
+}
+
+// Morgues returns the archived dumps of finished games, most recent first.
+func (g *Game) Morgues() ([]MorgueEntry, error) {
+	dir, err := g.MorgueDir()
+	if err != nil {
+		return nil, err
+	}
+	return morgueEntries(dir)
+}
+
+func morgueEntries(dir string) ([]MorgueEntry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "morgue-*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	entries := []MorgueEntry{}
+	for _, file := range files {
+		entry, ok := parseMorgueFile(file)
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// parseMorgueFile returns the entry described by the name of a morgue file,
+// of the form morgue[-NAME]-TIME-OUTCOME.txt.
+func parseMorgueFile(file string) (MorgueEntry, bool) {
+	base := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "morgue-"), ".txt")
+	i := strings.LastIndex(base, "-")
+	if i < len(morgueTimeLayout) {
+		return MorgueEntry{}, false
+	}
+	entry := MorgueEntry{File: file, Outcome: base[i+1:]}
+	base = base[:i]
+	t, err := time.ParseInLocation(morgueTimeLayout, base[len(base)-len(morgueTimeLayout):], time.Local)
+	if err != nil {
+		return MorgueEntry{}, false
+	}
+	entry.Time = t
+	entry.Name = strings.TrimSuffix(base[:len(base)-len(morgueTimeLayout)], "-")
+	return entry, true
+}
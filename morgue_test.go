@@ -0,0 +1,76 @@
+package boohu
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteMorgue(t *testing.T) {
+	dir := t.TempDir()
+	g, _ := newHeadlessGame(13, "")
+	g.Name = "Al-Mi"
+	g.Player.HP = 0
+	err := g.writeMorgue(dir, time.Date(2018, 1, 2, 15, 4, 5, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "morgue-Al-Mi-2018-01-02-150405-died.txt"); g.MorgueFile() != want {
+		t.Errorf("Morgue file %s instead of %s", g.MorgueFile(), want)
+	}
+	data, err := ioutil.ReadFile(g.MorgueFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("Character: Al-Mi")) {
+		t.Errorf("Morgue file without the character's name")
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(dir, "morgue-Al-Mi-2018-01-02-150405-died.json")); err != nil {
+		t.Errorf("No JSON dump in the morgue: %v", err)
+	}
+	g.Player.HP = 10
+	g.Abandoned = true
+	err = g.writeMorgue(dir, time.Date(2018, 1, 3, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(g.MorgueFile()) != "morgue-Al-Mi-2018-01-03-000000-abandoned.txt" {
+		t.Errorf("Bad morgue file for an abandoned game: %s", g.MorgueFile())
+	}
+}
+
+func TestMorgueEntries(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"morgue-Al-Mi-2018-01-02-150405-died.txt",
+		"morgue-2018-01-03-090000-won.txt",
+		"morgue-Bob-2018-01-01-120000-abandoned.txt",
+		"morgue-Bob-2018-01-01-120000-abandoned.json",
+		"morgue-broken.txt",
+		"dump-Bob.txt",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := morgueEntries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MorgueEntry{
+		{Name: "", Outcome: "won", Time: time.Date(2018, 1, 3, 9, 0, 0, 0, time.Local)},
+		{Name: "Al-Mi", Outcome: "died", Time: time.Date(2018, 1, 2, 15, 4, 5, 0, time.Local)},
+		{Name: "Bob", Outcome: "abandoned", Time: time.Date(2018, 1, 1, 12, 0, 0, 0, time.Local)},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Got %d morgue entries instead of %d: %+v", len(entries), len(want), entries)
+	}
+	for i, e := range entries {
+		w := want[i]
+		if e.Name != w.Name || e.Outcome != w.Outcome || !e.Time.Equal(w.Time) || filepath.Dir(e.File) != dir {
+			t.Errorf("Bad morgue entry %d: %+v instead of %+v", i, e, w)
+		}
+	}
+}
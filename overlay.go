@@ -0,0 +1,101 @@
+package boohu
+
+// Overlay is a view of the internal maps of the game, drawn over the
+// dungeon in wizard mode to understand the decisions of monsters and
+// automatic travel.
+type Overlay int
+
+const (
+	NoOverlay Overlay = iota
+	AutoexploreOverlay
+	NoiseOverlay
+	PathsOverlay
+	RaysOverlay
+)
+
+func (o Overlay) String() (text string) {
+	switch o {
+	case NoOverlay:
+		text = "none"
+	case AutoexploreOverlay:
+		text = "autoexplore costs"
+	case NoiseOverlay:
+		text = "last noise"
+	case PathsOverlay:
+		text = "monster paths"
+	case RaysOverlay:
+		text = "line of sight costs"
+	}
+	return text
+}
+
+// Next returns the overlay shown after o when cycling through them.
+func (o Overlay) Next() Overlay {
+	if o == RaysOverlay {
+		return NoOverlay
+	}
+	return o + 1
+}
+
+// overlayCost returns a single character for a cost: digits, then letters
+// from 10 to 35, and + for higher costs.
+func overlayCost(cost int) rune {
+	switch {
+	case cost < 0:
+		return '-'
+	case cost < 10:
+		return rune('0' + cost)
+	case cost < 36:
+		return rune('a' + cost - 10)
+	default:
+		return '+'
+	}
+}
+
+// OverlayMap returns the characters drawn by the overlay o:
+//
+// - AutoexploreOverlay: the cost of the explored positions in the autoexplore
+// map, computed by the last automatic exploration step, decreasing toward
+// the places to explore.
+// - NoiseOverlay: how loud the last noise was at each position, which a
+// monster not hunting has more chances to hear the higher it is.
+// - PathsOverlay: the cached path of each monster, with * for steps and X
+// for targets.
+// - RaysOverlay: the line of sight cost from the player, positions costing
+// less than 50 being in view.
+func (g *Game) OverlayMap(o Overlay) map[Position]rune {
+	m := map[Position]rune{}
+	switch o {
+	case AutoexploreOverlay:
+		for pos, n := range g.AutoexploreMap {
+			if c := g.Dungeon.Cell(pos); c.T == FreeCell && c.Explored {
+				m[pos] = overlayCost(n.Cost)
+			}
+		}
+	case NoiseOverlay:
+		for pos, n := range g.noiseMap {
+			if v := g.noiseLevel - n.Cost; v > 0 {
+				m[pos] = overlayCost(v)
+			}
+		}
+	case PathsOverlay:
+		for _, mons := range g.Monsters {
+			if !mons.Exists() || len(mons.Path) == 0 {
+				continue
+			}
+			for _, pos := range mons.Path {
+				if pos != mons.Pos {
+					m[pos] = '*'
+				}
+			}
+			m[mons.Target] = 'X'
+		}
+	case RaysOverlay:
+		for pos, n := range g.Player.Rays {
+			if pos != g.Player.Pos {
+				m[pos] = overlayCost(n.Cost)
+			}
+		}
+	}
+	return m
+}
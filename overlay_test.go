@@ -0,0 +1,33 @@
+package boohu
+
+import "testing"
+
+func TestOverlayMap(t *testing.T) {
+	g, _ := newHeadlessGame(11, "")
+	g.MakeNoise(20, g.Player.Pos)
+	noise := g.OverlayMap(NoiseOverlay)
+	if noise[g.Player.Pos] != overlayCost(20) {
+		t.Errorf("Bad noise at the source: %q", noise[g.Player.Pos])
+	}
+	for pos, r := range noise {
+		if pos.Distance(g.Player.Pos) > 20 {
+			t.Errorf("Noise %q too far at %v", r, pos)
+		}
+	}
+	rays := g.OverlayMap(RaysOverlay)
+	for pos := range g.Player.LOS {
+		if pos != g.Player.Pos && rays[pos] == 0 {
+			t.Errorf("No ray cost at %v in view", pos)
+		}
+	}
+	if len(g.OverlayMap(NoOverlay)) != 0 {
+		t.Errorf("Empty overlay with positions")
+	}
+	o := NoOverlay
+	for i := 0; i < 5; i++ {
+		o = o.Next()
+	}
+	if o != NoOverlay {
+		t.Errorf("Overlays do not cycle: %v", o)
+	}
+}
@@ -1,8 +1,8 @@
-package main
+package boohu
 
-func (d *dungeon) FreeNeighbors(pos position) []position {
-	neighbors := [8]position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
-	freeNeighbors := []position{}
+func (d *dungeon) FreeNeighbors(pos Position) []Position {
+	neighbors := [8]Position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
+	freeNeighbors := []Position{}
 	for _, npos := range neighbors {
 		if d.Valid(npos) && d.Cell(npos).T != WallCell {
 			freeNeighbors = append(freeNeighbors, npos)
@@ -11,9 +11,9 @@ func (d *dungeon) FreeNeighbors(pos position) []position {
 	return freeNeighbors
 }
 
-func (d *dungeon) CardinalFreeNeighbors(pos position) []position {
-	neighbors := [4]position{pos.E(), pos.W(), pos.N(), pos.S()}
-	freeNeighbors := []position{}
+func (d *dungeon) CardinalFreeNeighbors(pos Position) []Position {
+	neighbors := [4]Position{pos.E(), pos.W(), pos.N(), pos.S()}
+	freeNeighbors := []Position{}
 	for _, npos := range neighbors {
 		if d.Valid(npos) && d.Cell(npos).T != WallCell {
 			freeNeighbors = append(freeNeighbors, npos)
@@ -23,18 +23,18 @@ func (d *dungeon) CardinalFreeNeighbors(pos position) []position {
 }
 
 type playerPath struct {
-	game *game
+	game *Game
 }
 
-func (pp *playerPath) Neighbors(pos position) []position {
+func (pp *playerPath) Neighbors(pos Position) []Position {
 	m := pp.game.Dungeon
-	var neighbors []position
+	var neighbors []Position
 	if pp.game.Player.HasStatus(StatusConfusion) {
 		neighbors = m.CardinalFreeNeighbors(pos)
 	} else {
 		neighbors = m.FreeNeighbors(pos)
 	}
-	freeNeighbors := []position{}
+	freeNeighbors := []Position{}
 	for _, npos := range neighbors {
 		if m.Cell(npos).Explored && !pp.game.UnknownDig[npos] && !pp.game.ExclusionsMap[npos] {
 			freeNeighbors = append(freeNeighbors, npos)
@@ -43,44 +43,44 @@ func (pp *playerPath) Neighbors(pos position) []position {
 	return freeNeighbors
 }
 
-func (pp *playerPath) Cost(from, to position) int {
+func (pp *playerPath) Cost(from, to Position) int {
 	return 1
 }
 
-func (pp *playerPath) Estimation(from, to position) int {
+func (pp *playerPath) Estimation(from, to Position) int {
 	return from.Distance(to)
 }
 
 type normalPath struct {
-	game *game
+	game *Game
 }
 
-func (np *normalPath) Neighbors(pos position) []position {
+func (np *normalPath) Neighbors(pos Position) []Position {
 	if np.game.Player.HasStatus(StatusConfusion) {
 		return np.game.Dungeon.CardinalFreeNeighbors(pos)
 	}
 	return np.game.Dungeon.FreeNeighbors(pos)
 }
 
-func (np *normalPath) Cost(from, to position) int {
+func (np *normalPath) Cost(from, to Position) int {
 	return 1
 }
 
 type autoexplorePath struct {
-	game *game
+	game *Game
 }
 
-func (ap *autoexplorePath) Neighbors(pos position) []position {
+func (ap *autoexplorePath) Neighbors(pos Position) []Position {
 	if ap.game.ExclusionsMap[pos] {
 		return nil
 	}
-	var neighbors []position
+	var neighbors []Position
 	if ap.game.Player.HasStatus(StatusConfusion) {
 		neighbors = ap.game.Dungeon.CardinalFreeNeighbors(pos)
 	} else {
 		neighbors = ap.game.Dungeon.FreeNeighbors(pos)
 	}
-	var suitableNeighbors []position
+	var suitableNeighbors []Position
 	for _, pos := range neighbors {
 		if !ap.game.ExclusionsMap[pos] {
 			suitableNeighbors = append(suitableNeighbors, pos)
@@ -89,17 +89,17 @@ func (ap *autoexplorePath) Neighbors(pos position) []position {
 	return suitableNeighbors
 }
 
-func (ap *autoexplorePath) Cost(from, to position) int {
+func (ap *autoexplorePath) Cost(from, to Position) int {
 	return 1
 }
 
 type monPath struct {
-	game    *game
-	monster *monster
+	game    *Game
+	monster *Monster
 	wall    bool
 }
 
-func (mp *monPath) Neighbors(pos position) []position {
+func (mp *monPath) Neighbors(pos Position) []Position {
 	if mp.monster.Status(MonsConfused) {
 		if mp.wall {
 			return mp.game.Dungeon.CardinalNeighbors(pos)
@@ -112,9 +112,9 @@ func (mp *monPath) Neighbors(pos position) []position {
 	return mp.game.Dungeon.FreeNeighbors(pos)
 }
 
-func (mp *monPath) Cost(from, to position) int {
+func (mp *monPath) Cost(from, to Position) int {
 	g := mp.game
-	mons, _ := g.MonsterAt(to)
+	mons := g.MonsterAt(to)
 	if !mons.Exists() {
 		if mp.wall && g.Dungeon.Cell(to).T == WallCell && mp.monster.State != Hunting {
 			return 6
@@ -124,11 +124,11 @@ func (mp *monPath) Cost(from, to position) int {
 	return 4
 }
 
-func (mp *monPath) Estimation(from, to position) int {
+func (mp *monPath) Estimation(from, to Position) int {
 	return from.Distance(to)
 }
 
-func (m *monster) APath(g *game, from, to position) []position {
+func (m *Monster) APath(g *Game, from, to Position) []Position {
 	mp := &monPath{game: g, monster: m}
 	if m.Kind == MonsEarthDragon {
 		mp.wall = true
@@ -140,7 +140,7 @@ func (m *monster) APath(g *game, from, to position) []position {
 	return path
 }
 
-func (g *game) PlayerPath(from, to position) []position {
+func (g *Game) PlayerPath(from, to Position) []Position {
 	pp := &playerPath{game: g}
 	path, _, found := AstarPath(pp, from, to)
 	if !found {
@@ -1,20 +1,20 @@
-package main
+package boohu
 
 import (
-	"container/heap"
 	"errors"
 	"fmt"
 )
 
 type player struct {
-	LOS         map[position]bool
+	LOS         map[Position]bool
 	Rays        rayMap
-	Pos         position
+	Pos         Position
 	HP          int
 	MP          int
 	Consumables map[consumable]int
 	Gold        int
-	Target      position
+	Target      Position
+	TargetID    int // identifier of the targeted monster, 0 for none
 	Statuses    map[status]int
 	Armour      armour
 	Weapon      weapon
@@ -120,7 +120,7 @@ func (p *player) AptitudeCount() int {
 	return count
 }
 
-func (g *game) MoveToTarget(ev event) bool {
+func (g *Game) MoveToTarget(ev Event) bool {
 	if g.MonsterInLOS() == nil {
 		path := g.PlayerPath(g.Player.Pos, *g.AutoTarget)
 		if len(path) > 1 {
@@ -136,13 +136,32 @@ func (g *game) MoveToTarget(ev event) bool {
 	return false
 }
 
-func (g *game) WaitTurn(ev event) {
+// Travel starts travelling to pos. The next steps are then done
+// automatically while no monsters come into view.
+func (g *Game) Travel(pos Position, ev Event) error {
+	if pos == g.Player.Pos {
+		return errors.New("You are already there.")
+	}
+	ex := &Examiner{}
+	err := ex.Action(g, pos)
+	if err != nil {
+		return err
+	}
+	path := g.PlayerPath(g.Player.Pos, pos)
+	err = g.MovePlayer(path[len(path)-2], ev)
+	if err != nil {
+		g.AutoTarget = nil
+	}
+	return err
+}
+
+func (g *Game) WaitTurn(ev Event) {
 	// XXX Really wait for 10 ?
 	g.ScummingAction(ev)
 	ev.Renew(g, 10)
 }
 
-func (g *game) ExistsMonster() bool {
+func (g *Game) ExistsMonster() bool {
 	for _, mons := range g.Monsters {
 		if mons.Exists() {
 			return true
@@ -151,7 +170,7 @@ func (g *game) ExistsMonster() bool {
 	return false
 }
 
-func (g *game) ScummingAction(ev event) {
+func (g *Game) ScummingAction(ev Event) {
 	if g.Player.HP == g.Player.HPMax() {
 		g.Scumming++
 	}
@@ -165,36 +184,39 @@ func (g *game) ScummingAction(ev event) {
 			g.Scumming = 0
 			return
 		}
+		oldHP := g.Player.HP
 		g.Player.HP = g.Player.HP / 2
-		if RandInt(2) == 0 {
+		if g.Rand.Int(2) == 0 {
 			g.MakeNoise(100, g.Player.Pos)
 			neighbors := g.Dungeon.Neighbors(g.Player.Pos)
 			for _, pos := range neighbors {
-				if RandInt(3) != 0 {
+				if g.Rand.Int(3) != 0 {
 					g.Dungeon.SetCell(pos, FreeCell)
 				}
 			}
 			g.Print("You hear a terrible explosion coming from the ground. You are lignified.")
+			g.TakeDamage(nil, ExplosionAttack, oldHP-g.Player.HP, oldHP)
 			g.Player.Statuses[StatusLignification]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + 240 + RandInt(10), EAction: LignificationEnd})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + 240 + g.Rand.Int(10), EAction: LignificationEnd})
 		} else {
-			delay := 20 + RandInt(5)
+			delay := 20 + g.Rand.Int(5)
 			g.Player.Statuses[StatusTele]++
-			heap.Push(g.Events, &simpleEvent{ERank: ev.Rank() + delay, EAction: Teleportation})
+			g.PushEvent(&simpleEvent{ERank: ev.Rank() + delay, EAction: Teleportation})
 			g.Print("Something hurt you! You feel unstable.")
+			g.TakeDamage(nil, UnseenAttack, oldHP-g.Player.HP, oldHP)
 		}
 		g.Scumming = 0
 	}
 }
 
-func (g *game) FairAction() {
+func (g *Game) FairAction() {
 	g.Scumming -= 10
 	if g.Scumming < 0 {
 		g.Scumming = 0
 	}
 }
 
-func (g *game) Rest(ev event) error {
+func (g *Game) Rest(ev Event) error {
 	if g.MonsterInLOS() != nil {
 		return fmt.Errorf("You cannot sleep while monsters are in view.")
 	}
@@ -207,7 +229,7 @@ func (g *game) Rest(ev event) error {
 	return nil
 }
 
-func (g *game) Equip(ev event) error {
+func (g *Game) Equip(ev Event) error {
 	if eq, ok := g.Equipables[g.Player.Pos]; ok {
 		eq.Equip(g)
 		ev.Renew(g, 10)
@@ -216,8 +238,8 @@ func (g *game) Equip(ev event) error {
 	return errors.New("Found nothing to equip here.")
 }
 
-func (g *game) Teleportation(ev event) {
-	var pos position
+func (g *Game) Teleportation(ev Event) {
+	var pos Position
 	i := 0
 	count := 0
 	for {
@@ -245,7 +267,7 @@ func (g *game) Teleportation(ev event) {
 	}
 }
 
-func (g *game) MovePlayer(pos position, ev event) error {
+func (g *Game) MovePlayer(pos Position, ev Event) error {
 	if !g.Dungeon.Valid(pos) || g.Dungeon.Cell(pos).T == WallCell {
 		return errors.New("You cannot move there.")
 	}
@@ -259,7 +281,7 @@ func (g *game) MovePlayer(pos position, ev event) error {
 	delay := 10
 	switch g.Dungeon.Cell(pos).T {
 	case FreeCell:
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if !mons.Exists() {
 			if g.Player.HasStatus(StatusLignification) {
 				return errors.New("You cannot move while lignified")
@@ -318,7 +340,7 @@ func (g *game) MovePlayer(pos position, ev event) error {
 	return nil
 }
 
-func (g *game) HealPlayer(ev event) {
+func (g *Game) HealPlayer(ev Event) {
 	if g.Player.HP < g.Player.HPMax() {
 		g.Player.HP++
 	}
@@ -329,7 +351,7 @@ func (g *game) HealPlayer(ev event) {
 	ev.Renew(g, delay)
 }
 
-func (g *game) MPRegen(ev event) {
+func (g *Game) MPRegen(ev Event) {
 	if g.Player.MP < g.Player.MPMax() {
 		g.Player.MP++
 	}
@@ -1,43 +1,43 @@
-package main
+package boohu
 
-type position struct {
+type Position struct {
 	X int
 	Y int
 }
 
-func (pos position) E() position {
-	return position{pos.X + 1, pos.Y}
+func (pos Position) E() Position {
+	return Position{pos.X + 1, pos.Y}
 }
 
-func (pos position) SE() position {
-	return position{pos.X + 1, pos.Y + 1}
+func (pos Position) SE() Position {
+	return Position{pos.X + 1, pos.Y + 1}
 }
 
-func (pos position) NE() position {
-	return position{pos.X + 1, pos.Y - 1}
+func (pos Position) NE() Position {
+	return Position{pos.X + 1, pos.Y - 1}
 }
 
-func (pos position) N() position {
-	return position{pos.X, pos.Y - 1}
+func (pos Position) N() Position {
+	return Position{pos.X, pos.Y - 1}
 }
 
-func (pos position) S() position {
-	return position{pos.X, pos.Y + 1}
+func (pos Position) S() Position {
+	return Position{pos.X, pos.Y + 1}
 }
 
-func (pos position) W() position {
-	return position{pos.X - 1, pos.Y}
+func (pos Position) W() Position {
+	return Position{pos.X - 1, pos.Y}
 }
 
-func (pos position) SW() position {
-	return position{pos.X - 1, pos.Y + 1}
+func (pos Position) SW() Position {
+	return Position{pos.X - 1, pos.Y + 1}
 }
 
-func (pos position) NW() position {
-	return position{pos.X - 1, pos.Y - 1}
+func (pos Position) NW() Position {
+	return Position{pos.X - 1, pos.Y - 1}
 }
 
-func (pos position) Distance(to position) int {
+func (pos Position) Distance(to Position) int {
 	deltaX := Abs(to.X - pos.X)
 	deltaY := Abs(to.Y - pos.Y)
 	if deltaX > deltaY {
@@ -67,7 +67,7 @@ const (
 	ESE
 )
 
-func (pos position) To(dir direction) position {
+func (pos Position) To(dir direction) Position {
 	to := pos
 	switch dir {
 	case E, ENE, ESE:
@@ -90,7 +90,7 @@ func (pos position) To(dir direction) position {
 	return to
 }
 
-func (pos position) Dir(from position) direction {
+func (pos Position) Dir(from Position) direction {
 	deltaX := Abs(pos.X - from.X)
 	deltaY := Abs(pos.Y - from.Y)
 	switch {
@@ -143,8 +143,8 @@ func (pos position) Dir(from position) direction {
 	}
 }
 
-func (pos position) Parents(from position) []position {
-	p := []position{}
+func (pos Position) Parents(from Position) []Position {
+	p := []Position{}
 	switch pos.Dir(from) {
 	case E:
 		p = append(p, pos.W())
@@ -182,37 +182,37 @@ func (pos position) Parents(from position) []position {
 	return p
 }
 
-func (pos position) RandomNeighbor(diag bool) position {
+func (pos Position) RandomNeighbor(rnd *rng, diag bool) Position {
 	if diag {
-		return pos.RandomNeighborDiagonals()
+		return pos.RandomNeighborDiagonals(rnd)
 	}
-	return pos.RandomNeighborCardinal()
+	return pos.RandomNeighborCardinal(rnd)
 }
 
-func (pos position) RandomNeighborDiagonals() position {
-	neighbors := [8]position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
+func (pos Position) RandomNeighborDiagonals(rnd *rng) Position {
+	neighbors := [8]Position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
 	var r int
-	switch RandInt(8) {
+	switch rnd.Int(8) {
 	case 0:
-		r = RandInt(len(neighbors[0:4]))
+		r = rnd.Int(len(neighbors[0:4]))
 	case 1:
-		r = RandInt(len(neighbors[0:2]))
+		r = rnd.Int(len(neighbors[0:2]))
 	default:
-		r = RandInt(len(neighbors[4:]))
+		r = rnd.Int(len(neighbors[4:]))
 	}
 	return neighbors[r]
 }
 
-func (pos position) RandomNeighborCardinal() position {
-	neighbors := [8]position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
+func (pos Position) RandomNeighborCardinal(rnd *rng) Position {
+	neighbors := [8]Position{pos.E(), pos.W(), pos.N(), pos.S(), pos.NE(), pos.NW(), pos.SE(), pos.SW()}
 	var r int
-	switch RandInt(6) {
+	switch rnd.Int(6) {
 	case 0:
-		r = RandInt(len(neighbors[0:4]))
+		r = rnd.Int(len(neighbors[0:4]))
 	case 1:
-		r = RandInt(len(neighbors))
+		r = rnd.Int(len(neighbors))
 	default:
-		r = RandInt(len(neighbors[0:2]))
+		r = rnd.Int(len(neighbors[0:2]))
 	}
 	return neighbors[r]
 }
@@ -1,32 +1,32 @@
-package main
+package boohu
 
 import "testing"
 
 func TestDir(t *testing.T) {
 	type tableTest struct {
-		pos position
+		pos Position
 		dir direction
 	}
 	table := []tableTest{
-		{position{3, 2}, E},
-		{position{4, 1}, ENE},
-		{position{3, 1}, NE},
-		{position{3, 0}, NNE},
-		{position{2, 1}, N},
-		{position{1, 0}, NNW},
-		{position{1, 1}, NW},
-		{position{0, 1}, WNW},
-		{position{1, 2}, W},
-		{position{0, 3}, WSW},
-		{position{1, 3}, SW},
-		{position{1, 4}, SSW},
-		{position{2, 3}, S},
-		{position{3, 4}, SSE},
-		{position{3, 3}, SE},
-		{position{4, 3}, ESE},
+		{Position{3, 2}, E},
+		{Position{4, 1}, ENE},
+		{Position{3, 1}, NE},
+		{Position{3, 0}, NNE},
+		{Position{2, 1}, N},
+		{Position{1, 0}, NNW},
+		{Position{1, 1}, NW},
+		{Position{0, 1}, WNW},
+		{Position{1, 2}, W},
+		{Position{0, 3}, WSW},
+		{Position{1, 3}, SW},
+		{Position{1, 4}, SSW},
+		{Position{2, 3}, S},
+		{Position{3, 4}, SSE},
+		{Position{3, 3}, SE},
+		{Position{4, 3}, ESE},
 	}
 	for _, test := range table {
-		if test.pos.Dir(position{2, 2}) != test.dir {
+		if test.pos.Dir(Position{2, 2}) != test.dir {
 			t.Errorf("Bad direction for %+v\n", test)
 		}
 	}
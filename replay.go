@@ -0,0 +1,122 @@
+package boohu
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+type inputKind int
+
+const (
+	KeyInput inputKind = iota
+	ExploreInput
+	ExploreStopInput
+	CriticalHPInput
+	RestStopInput
+)
+
+// ReplayInput is a player input recorded during a game. Auto-explore steps
+// are recorded too, because the player can interrupt them at any time, as
+// well as changes of the CriticalHP setting, which change the inputs needed,
+// and rests interrupted by the renderer.
+type ReplayInput struct {
+	Kind inputKind
+	Ch   rune
+	Key  uint16
+	HP   int // new CriticalHP of a CriticalHPInput
+}
+
+type Replay struct {
+	Seed       int64
+	CriticalHP int // the game's CriticalHP at the start of the game
+	Inputs     []ReplayInput
+}
+
+func (g *Game) RecordInput(in ReplayInput) {
+	g.Inputs = append(g.Inputs, in)
+}
+
+// SetCriticalHP changes the HP under which the player is warned, which is a
+// setting that can change between two sessions of the same game. The change
+// is recorded for replays, as warnings wait for a key.
+func (g *Game) SetCriticalHP(hp int) {
+	if hp == g.CriticalHP {
+		return
+	}
+	if !g.criticalHPChanged() {
+		g.StartCriticalHP = g.CriticalHP
+	}
+	g.RecordInput(ReplayInput{Kind: CriticalHPInput, HP: hp})
+	g.CriticalHP = hp
+}
+
+func (g *Game) criticalHPChanged() bool {
+	for _, in := range g.Inputs {
+		if in.Kind == CriticalHPInput {
+			return true
+		}
+	}
+	return false
+}
+
+// replayCriticalHP returns the CriticalHP the game started with.
+func (g *Game) replayCriticalHP() int {
+	if g.criticalHPChanged() {
+		return g.StartCriticalHP
+	}
+	return g.CriticalHP
+}
+
+func (g *Game) WriteReplay() error {
+	if g.noSave {
+		return nil
+	}
+	replayFile, err := g.characterFile("replay", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing replay: %s", err)
+		return err
+	}
+	var data bytes.Buffer
+	enc := gob.NewEncoder(&data)
+	err = enc.Encode(&Replay{Seed: g.Seed, CriticalHP: g.replayCriticalHP(), Inputs: g.Inputs})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing replay: %s", err)
+		return err
+	}
+	err = ioutil.WriteFile(replayFile, data.Bytes(), 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing replay: %s", err)
+		return err
+	}
+	return nil
+}
+
+func LoadReplay(file string) (*Replay, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	dec := gob.NewDecoder(bytes.NewBuffer(data))
+	rep := &Replay{}
+	err = dec.Decode(rep)
+	if err != nil {
+		return nil, err
+	}
+	if len(rep.Inputs) == 0 {
+		return nil, errors.New("empty replay")
+	}
+	return rep, nil
+}
+
+// NewReplayGame returns a new game for playing back rep. Such a game never
+// writes any save, dump or replay file.
+func NewReplayGame(rep *Replay, ui Renderer) *Game {
+	g := &Game{noSave: true, ui: ui, CriticalHP: rep.CriticalHP}
+	g.SetSeed(rep.Seed)
+	g.InitLevel()
+	return g
+}
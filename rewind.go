@@ -0,0 +1,88 @@
+package boohu
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+)
+
+// maxSnapshots is the number of states of player turns kept for rewinding in
+// wizard mode.
+const maxSnapshots = 100
+
+// snapshot is the state of the game at the start of a player's turn. The
+// messages and the story, which only grow during the game, are shared with
+// the game instead of being encoded with it. The recorded inputs are not
+// needed, as they are kept when rewinding.
+type snapshot struct {
+	data  []byte // game encoded as in save files, without log, story and inputs
+	log   []string
+	story []string
+}
+
+// snapshot records the state of the game at the start of the player's turn
+// ev. Snapshots are only taken in wizard mode.
+func (g *Game) snapshot(ev Event) {
+	g.rewound = false
+	if !g.Wizard {
+		return
+	}
+	log, story, inputs := g.Log, g.Story, g.Inputs
+	g.Log, g.Story, g.Inputs = nil, nil, nil
+	// ev is encoded in the event queue with its sequence number, as in
+	// SaveTurn, so that the restored game plays this turn again. It was the
+	// first event of the queue, so popping it afterwards removes it again.
+	heap.Push(g.Events, ev)
+	data, err := g.encodeSave()
+	heap.Pop(g.Events)
+	g.Log, g.Story, g.Inputs = log, story, inputs
+	if err != nil {
+		g.Print(err.Error())
+		return
+	}
+	if len(g.snapshots) >= maxSnapshots {
+		g.snapshots = g.snapshots[1:]
+	}
+	g.snapshots = append(g.snapshots, snapshot{data: data, log: log, story: story})
+}
+
+// Rewind restores the state of the game at the start of the n-th previous
+// player turn, including the event queue and the random number generator.
+// The recorded inputs are kept, so that replays rewind too. The current
+// player turn must end without any action afterwards, as reported by
+// Rewound.
+func (g *Game) Rewind(n int) error {
+	// the last snapshot is the one of the current turn
+	max := len(g.snapshots) - 1
+	if max < 1 {
+		return errors.New("There is no previous turn to rewind to.")
+	}
+	if n < 1 || n > max {
+		return fmt.Errorf("The number of turns must be between 1 and %d.", max)
+	}
+	i := len(g.snapshots) - 1 - n
+	sn := g.snapshots[i]
+	lg, err := decodeSave(sn.data)
+	if err != nil {
+		return err
+	}
+	lg.ui = g.ui
+	lg.noSave = g.noSave
+	lg.Inputs = g.Inputs
+	// new messages must not be written in the arrays shared with the
+	// snapshots
+	lg.Log = sn.log[:len(sn.log):len(sn.log)]
+	lg.Story = sn.story[:len(sn.story):len(sn.story)]
+	// the snapshot of the restored turn is taken again when it starts
+	lg.snapshots = g.snapshots[:i]
+	lg.rewound = true
+	*g = *lg
+	g.IndexMonsters()
+	return nil
+}
+
+// Rewound reports whether the game was rewound during the current player
+// turn.
+func (g *Game) Rewound() bool {
+	return g.rewound
+}
@@ -0,0 +1,59 @@
+package boohu
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"log"
+	"time"
+)
+
+// rng is a splitmix64 pseudo-random number generator. Its state is an
+// exported field so that it is saved along with the game: a game started
+// with a given seed can be reproduced exactly.
+type rng struct {
+	State uint64
+}
+
+func (r *rng) Seed(seed int64) {
+	r.State = uint64(seed)
+}
+
+func (r *rng) Uint64() uint64 {
+	r.State += 0x9e3779b97f4a7c15
+	z := r.State
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int returns a pseudo-random number in [0,n), or 0 if n <= 0.
+func (r *rng) Int(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	un := uint64(n)
+	// reject values in the last incomplete interval to avoid modulo bias
+	max := ^uint64(0) - ^uint64(0)%un
+	x := r.Uint64()
+	for x >= max {
+		x = r.Uint64()
+	}
+	return int(x % un)
+}
+
+// RandomSeed returns a seed suitable for a new game when the player did not
+// ask for a particular one.
+func RandomSeed() int64 {
+	var b [8]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		log.Println(err)
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]))
+}
+
+func (g *Game) SetSeed(seed int64) {
+	g.Seed = seed
+	g.Rand.Seed(seed)
+}
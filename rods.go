@@ -1,7 +1,6 @@
-package main
+package boohu
 
 import (
-	"container/heap"
 	"errors"
 	"fmt"
 )
@@ -125,15 +124,45 @@ func (r rod) MPCost() (mp int) {
 	return mp
 }
 
-func (r rod) Use(g *game, ev event) error {
+// Targetter returns the targetter for choosing the target of the rod, or nil
+// if the rod does not need a target. The target is expected in
+// g.Player.Target when the rod is used, along with g.Player.TargetID for
+// a targeted monster.
+func (r rod) Targetter() Targetter {
+	switch r {
+	case RodTeleportOther, RodLightningBolt:
+		return &chooser{}
+	case RodFireball:
+		return &chooser{area: true, minDist: true}
+	case RodDigging:
+		return &wallChooser{}
+	case RodShatter:
+		return &wallChooser{minDist: true}
+	default:
+		return nil
+	}
+}
+
+func (r rod) CanEvoke(g *Game) error {
 	rods := g.Player.Rods
+	if rods[r] == nil {
+		return errors.New("You do not have such a rod.")
+	}
 	if rods[r].Charge <= 0 {
 		return errors.New("No charges remaining on this rod.")
 	}
 	if r.MPCost() > g.Player.MP {
 		return errors.New("Not enough magic points for using this rod.")
 	}
-	var err error
+	return nil
+}
+
+func (r rod) Use(g *Game, ev Event) error {
+	err := r.CanEvoke(g)
+	if err != nil {
+		return err
+	}
+	rods := g.Player.Rods
 	switch r {
 	case RodBlink:
 		err = g.EvokeRodBlink(ev)
@@ -156,13 +185,17 @@ func (r rod) Use(g *game, ev event) error {
 	}
 	rods[r].Charge--
 	g.Player.MP -= r.MPCost()
+	if g.Evoked == nil {
+		g.Evoked = map[rod]int{}
+	}
+	g.Evoked[r]++
 	g.StoryPrintf("You evoked your %s.", r)
 	g.FairAction()
 	ev.Renew(g, 7)
 	return nil
 }
 
-func (g *game) EvokeRodBlink(ev event) error {
+func (g *Game) EvokeRodBlink(ev Event) error {
 	if g.Player.HasStatus(StatusLignification) {
 		return errors.New("You cannot blink while lignified.")
 	}
@@ -170,19 +203,22 @@ func (g *game) EvokeRodBlink(ev event) error {
 	return nil
 }
 
-func (g *game) Blink(ev event) {
+func (g *Game) Blink(ev Event) {
 	if g.Player.HasStatus(StatusLignification) {
 		return
 	}
-	losPos := []position{}
-	for pos, b := range g.Player.LOS {
-		if !b {
+	losPos := []Position{}
+	for i := range g.Dungeon.Cells {
+		// iterate in dungeon order, so that the blink only depends on the
+		// game seed
+		pos := g.Dungeon.CellPosition(i)
+		if !g.Player.LOS[pos] {
 			continue
 		}
 		if g.Dungeon.Cell(pos).T != FreeCell {
 			continue
 		}
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons.Exists() {
 			continue
 		}
@@ -193,10 +229,10 @@ func (g *game) Blink(ev event) {
 		g.Print("You could not blink.")
 		return
 	}
-	npos := losPos[RandInt(len(losPos))]
+	npos := losPos[g.Rand.Int(len(losPos))]
 	if npos.Distance(g.Player.Pos) <= 3 {
 		// Give close cells less chance to make blinking more useful
-		npos = losPos[RandInt(len(losPos))]
+		npos = losPos[g.Rand.Int(len(losPos))]
 	}
 	g.Player.Pos = npos
 	g.Print("You blink away.")
@@ -204,11 +240,8 @@ func (g *game) Blink(ev event) {
 	g.MakeMonstersAware()
 }
 
-func (g *game) EvokeRodTeleportOther(ev event) error {
-	if !g.ui.ChooseTarget(g, &chooser{}) {
-		return errors.New("Ok, then.")
-	}
-	mons, _ := g.MonsterAt(g.Player.Target)
+func (g *Game) EvokeRodTeleportOther(ev Event) error {
+	mons := g.MonsterByID(g.Player.TargetID)
 	if mons == nil {
 		// should not happen (done in the targeter)
 		return errors.New("You must target a monster for using this rod.")
@@ -242,18 +275,15 @@ func (g *game) EvokeRodTeleportOther(ev event) error {
 	return nil
 }
 
-func (g *game) EvokeRodLightningBolt(ev event) error {
-	if !g.ui.ChooseTarget(g, &chooser{}) {
-		return errors.New("Ok, then.")
-	}
+func (g *Game) EvokeRodLightningBolt(ev Event) error {
 	ray := g.Ray(g.Player.Target)
 	g.Print("A lightning bolt emerges straight from the rod.")
 	for _, pos := range ray {
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons == nil {
 			continue
 		}
-		mons.HP -= RandInt(21)
+		mons.HP -= g.Rand.Int(21)
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by the bolt.", Indefinite(mons.Kind.String(), true))
 			g.KillStats(mons)
@@ -264,18 +294,15 @@ func (g *game) EvokeRodLightningBolt(ev event) error {
 	return nil
 }
 
-func (g *game) EvokeRodFireball(ev event) error {
-	if !g.ui.ChooseTarget(g, &chooser{area: true, minDist: true}) {
-		return errors.New("Ok, then.")
-	}
+func (g *Game) EvokeRodFireball(ev Event) error {
 	neighbors := g.Dungeon.FreeNeighbors(g.Player.Target)
 	g.Print("A fireball emerges straight from the rod.")
 	for _, pos := range append(neighbors, g.Player.Target) {
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons == nil {
 			continue
 		}
-		mons.HP -= RandInt(21)
+		mons.HP -= g.Rand.Int(21)
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by the fireball.", Indefinite(mons.Kind.String(), true))
 			g.KillStats(mons)
@@ -292,14 +319,18 @@ const (
 	CloudFog cloud = iota
 )
 
-func (g *game) EvokeRodFog(ev event) error {
+func (g *Game) EvokeRodFog(ev Event) error {
 	dij := &normalPath{game: g}
-	nm := Dijkstra(dij, []position{g.Player.Pos}, 3)
-	for pos := range nm {
+	nm := Dijkstra(dij, []Position{g.Player.Pos}, 3)
+	for i := range g.Dungeon.Cells {
+		pos := g.Dungeon.CellPosition(i)
+		if _, ok := nm[pos]; !ok {
+			continue
+		}
 		_, ok := g.Clouds[pos]
 		if !ok {
 			g.Clouds[pos] = CloudFog
-			heap.Push(g.Events, &cloudEvent{ERank: ev.Rank() + 100 + RandInt(100), EAction: CloudEnd, Pos: pos})
+			g.PushEvent(&cloudEvent{ERank: ev.Rank() + 100 + g.Rand.Int(100), EAction: CloudEnd, Pos: pos})
 		}
 	}
 	g.ComputeLOS()
@@ -307,10 +338,7 @@ func (g *game) EvokeRodFog(ev event) error {
 	return nil
 }
 
-func (g *game) EvokeRodDigging(ev event) error {
-	if !g.ui.ChooseTarget(g, &wallChooser{}) {
-		return errors.New("Ok, then.")
-	}
+func (g *Game) EvokeRodDigging(ev Event) error {
 	pos := g.Player.Target
 	for i := 0; i < 3; i++ {
 		g.Dungeon.SetCell(pos, FreeCell)
@@ -329,12 +357,9 @@ func (g *game) EvokeRodDigging(ev event) error {
 	return nil
 }
 
-func (g *game) EvokeRodShatter(ev event) error {
-	if !g.ui.ChooseTarget(g, &wallChooser{minDist: true}) {
-		return errors.New("Ok, then.")
-	}
+func (g *Game) EvokeRodShatter(ev Event) error {
 	neighbors := g.Dungeon.FreeNeighbors(g.Player.Target)
-	if RandInt(2) == 0 {
+	if g.Rand.Int(2) == 0 {
 		g.Dungeon.SetCell(g.Player.Target, FreeCell)
 		g.ComputeLOS()
 		g.MakeMonstersAware()
@@ -345,11 +370,11 @@ func (g *game) EvokeRodShatter(ev event) error {
 		g.Print("You see an explosion around the wall.")
 	}
 	for _, pos := range neighbors {
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons == nil {
 			continue
 		}
-		mons.HP -= RandInt(30)
+		mons.HP -= g.Rand.Int(30)
 		if mons.HP <= 0 {
 			g.Printf("%s is killed by the explosion.", Indefinite(mons.Kind.String(), true))
 			g.KillStats(mons)
@@ -360,7 +385,7 @@ func (g *game) EvokeRodShatter(ev event) error {
 	return nil
 }
 
-func (g *game) GeneratedRodsCount() int {
+func (g *Game) GeneratedRodsCount() int {
 	count := 0
 	for _, b := range g.GeneratedRods {
 		if b {
@@ -370,7 +395,7 @@ func (g *game) GeneratedRodsCount() int {
 	return count
 }
 
-func (g *game) GenerateRod() {
+func (g *Game) GenerateRod() {
 	count := 0
 	for {
 		count++
@@ -378,9 +403,9 @@ func (g *game) GenerateRod() {
 			panic("GenerateRod")
 		}
 		pos := g.FreeCellForStatic()
-		r := rod(RandInt(int(RodShatter) + 1))
+		r := rod(g.Rand.Int(int(RodShatter) + 1))
 		if r.Rare() {
-			r = rod(RandInt(int(RodShatter) + 1))
+			r = rod(g.Rand.Int(int(RodShatter) + 1))
 		}
 		if g.Player.Rods[r] == nil && !g.GeneratedRods[r] {
 			g.GeneratedRods[r] = true
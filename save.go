@@ -1,26 +1,34 @@
-package main
+package boohu
 
 import (
 	"bytes"
+	"container/heap"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
 func init() {
-	gob.Register(potion(0))
-	gob.Register(projectile(0))
-	gob.Register(&simpleEvent{})
-	gob.Register(&monsterEvent{})
-	gob.Register(&cloudEvent{})
-	gob.Register(armour(0))
-	gob.Register(weapon(0))
-	gob.Register(shield(0))
+	// The names are the ones used when the game was a single main package,
+	// so that older save files can still be decoded.
+	gob.RegisterName("main.potion", potion(0))
+	gob.RegisterName("main.projectile", projectile(0))
+	gob.RegisterName("*main.simpleEvent", &simpleEvent{})
+	gob.RegisterName("*main.monsterEvent", &monsterEvent{})
+	gob.RegisterName("*main.cloudEvent", &cloudEvent{})
+	gob.RegisterName("main.armour", armour(0))
+	gob.RegisterName("main.weapon", weapon(0))
+	gob.RegisterName("main.shield", shield(0))
 }
 
-func (g *game) DataDir() (string, error) {
+func (g *Game) DataDir() (string, error) {
 	var xdg string
 	if os.Getenv("GOOS") == "windows" {
 		xdg = os.Getenv("LOCALAPPDATA")
@@ -41,64 +49,443 @@ func (g *game) DataDir() (string, error) {
 	return dataDir, nil
 }
 
-func (g *game) Save() {
+// saveMagic starts every save file written since the format is versioned.
+const saveMagic = "boohu save\n"
+
+// SaveFormat is the version of the save file format. It has to be increased,
+// and a migration added to saveMigrations, whenever a change to the saved
+// structures would make older saves load incorrectly.
+const SaveFormat = 2
+
+type saveHeader struct {
+	Format   int
+	Version  string
+	Checksum uint32 // CRC-32 of the encoded game, since format 2
+}
+
+// saveMigrations maps a save format to the function that upgrades a game
+// loaded in that format to the next one. Format 0 is the format of saves
+// without header.
+var saveMigrations = map[int]func(*Game) error{
+	0: (*Game).convertMonsterIndices,
+	1: func(*Game) error { return nil }, // only the header changed
+}
+
+// SaveVersionError is returned by Load when the save file cannot be upgraded
+// to the current format.
+type SaveVersionError struct {
+	File    string
+	Format  int
+	Version string
+}
+
+func (e *SaveVersionError) Error() string {
+	if e.Format > SaveFormat {
+		return fmt.Sprintf("%s was saved by a newer version of the game (%s)", e.File, e.Version)
+	}
+	return fmt.Sprintf("%s was saved by an older version of the game that cannot be upgraded (save format %d)", e.File, e.Format)
+}
+
+// SaveCorruptedError is returned by Load when the save file is missing or
+// damaged while a previous save may still be available as a backup.
+type SaveCorruptedError struct {
+	File   string
+	Err    error
+	Backup bool // whether a backup of the previous save exists
+}
+
+func (e *SaveCorruptedError) Error() string {
+	return fmt.Sprintf("%s is damaged: %v", e.File, e.Err)
+}
+
+// characterFile returns the path of a file of the current character in the
+// data directory, made of prefix, the character's name and ext. Unnamed
+// characters use the file names of versions without characters.
+func (g *Game) characterFile(prefix, ext string) (string, error) {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return "", err
+	}
+	file := prefix
+	if g.Name != "" {
+		file += "-" + fileName(g.Name)
+	}
+	return filepath.Join(dataDir, file+ext), nil
+}
+
+// fileName returns name with any characters that are not safe in a file
+// name replaced.
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// saveFiles returns the paths of the save file, of its backup, and of the
+// consumed save, that is the last loaded save, which is kept for recovering
+// the game if it does not end properly, and becomes the backup at the next
+// save.
+func (g *Game) saveFiles() (save, backup, consumed string, err error) {
+	save, err = g.characterFile("save", ".gob")
+	if err != nil {
+		return "", "", "", err
+	}
+	return save, save + ".bak", save + ".consumed", nil
+}
+
+// SaveSlot describes the saved game of a character.
+type SaveSlot struct {
+	Name       string
+	Depth      int
+	Turn       int
+	LastPlayed time.Time
+	Unfinished bool   // the game did not end properly since it was loaded
+	AutoSaved  bool   // the game was saved when the terminal was closed
+	Daily      string // date of the daily challenge, if any
+	Err        error  // the save could not be read
+}
+
+// SaveSlots returns the saved games of every character, most recently
+// played first.
+func (g *Game) SaveSlots() ([]SaveSlot, error) {
 	dataDir, err := g.DataDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dataDir, "save*.gob*"))
+	if err != nil {
+		return nil, err
+	}
+	bases := map[string]bool{}
+	for _, file := range files {
+		base := strings.TrimSuffix(strings.TrimSuffix(file, ".bak"), ".consumed")
+		if strings.HasSuffix(base, ".gob") {
+			bases[base] = true
+		}
+	}
+	slots := []SaveSlot{}
+	for base := range bases {
+		slots = append(slots, readSaveSlot(base))
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].LastPlayed.After(slots[j].LastPlayed)
+	})
+	return slots, nil
+}
+
+func readSaveSlot(base string) SaveSlot {
+	name := strings.TrimSuffix(filepath.Base(base), ".gob")
+	slot := SaveSlot{Name: strings.TrimPrefix(strings.TrimPrefix(name, "save"), "-")}
+	var file string
+	for _, f := range []string{base, base + ".consumed", base + ".bak"} {
+		fi, err := os.Stat(f)
+		if err == nil {
+			file = f
+			slot.LastPlayed = fi.ModTime()
+			break
+		}
+	}
+	switch file {
+	case base + ".consumed":
+		slot.Unfinished = true
+	case base + ".bak":
+		// interrupted while replacing the save file
+		slot.Err = errors.New("missing file")
+		return slot
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		slot.Err = err
+		return slot
+	}
+	g, err := decodeSave(data)
+	if err != nil {
+		slot.Err = err
+		return slot
+	}
+	slot.Name = g.Name
+	slot.Depth = g.Depth
+	slot.Turn = g.Turn
+	slot.AutoSaved = g.AutoSaved
+	slot.Daily = g.Daily
+	return slot
+}
+
+func (g *Game) Save() {
+	if g.noSave {
+		return
+	}
+	saveFile, backupFile, consumedFile, err := g.saveFiles()
 	if err != nil {
 		g.Print(err.Error())
 		return
 	}
-	saveFile := filepath.Join(dataDir, "save.gob")
-	var data bytes.Buffer
-	enc := gob.NewEncoder(&data)
-	err = enc.Encode(g)
+	data, err := g.encodeSave()
 	if err != nil {
 		g.Print(err.Error())
 		return
 	}
-	err = ioutil.WriteFile(saveFile, data.Bytes(), 0644)
+	if _, err := os.Stat(consumedFile); err == nil {
+		// the loaded save is the previous one, kept as backup
+		err = os.Rename(consumedFile, backupFile)
+		if err != nil {
+			g.Print(err.Error())
+			return
+		}
+	}
+	err = writeSaveFile(saveFile, backupFile, data)
 	if err != nil {
 		g.Print(err.Error())
 	}
 }
 
-func (g *game) RemoveSaveFile() {
-	dataDir, err := g.DataDir()
+// SaveTurn saves the game during the player's turn ev, which is played again
+// when the game is loaded.
+func (g *Game) SaveTurn(ev Event) {
+	// put back the event with its sequence number, as if it was never handled,
+	// so that the loaded game plays exactly as if it had not been interrupted
+	heap.Push(g.Events, ev)
+	g.Save()
+}
+
+func (g *Game) encodeSave() ([]byte, error) {
+	var payload bytes.Buffer
+	err := gob.NewEncoder(&payload).Encode(g)
 	if err != nil {
-		g.Print(err.Error())
-		return
+		return nil, err
 	}
-	saveFile := filepath.Join(dataDir, "save.gob")
-	_, err = os.Stat(saveFile)
+	var data bytes.Buffer
+	data.WriteString(saveMagic)
+	hdr := &saveHeader{Format: SaveFormat, Version: Version, Checksum: crc32.ChecksumIEEE(payload.Bytes())}
+	err = gob.NewEncoder(&data).Encode(hdr)
+	if err != nil {
+		return nil, err
+	}
+	data.Write(payload.Bytes())
+	return data.Bytes(), nil
+}
+
+// writeSaveFile writes data to a temporary file that then replaces file, so
+// that a crash never leaves a partially written save. The replaced file is
+// kept as backup.
+func writeSaveFile(file, backup string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), "save-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
 	if err == nil {
-		err := os.Remove(saveFile)
+		err = tmp.Sync()
+	}
+	cerr := tmp.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := os.Stat(file); err == nil {
+		err = os.Rename(file, backup)
 		if err != nil {
-			fmt.Fprint(os.Stderr, "Error removing old save file")
+			os.Remove(tmp.Name())
+			return err
 		}
 	}
+	err = os.Rename(tmp.Name(), file)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	// make the renaming durable too; not possible on every system
+	if dir, err := os.Open(filepath.Dir(file)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
 }
 
-func (g *game) Load() (bool, error) {
-	dataDir, err := g.DataDir()
+func (g *Game) RemoveSaveFile() {
+	if g.noSave {
+		return
+	}
+	saveFile, backupFile, consumedFile, err := g.saveFiles()
+	if err != nil {
+		g.Print(err.Error())
+		return
+	}
+	for _, file := range []string{saveFile, backupFile, consumedFile} {
+		_, err = os.Stat(file)
+		if err == nil {
+			err := os.Remove(file)
+			if err != nil {
+				fmt.Fprint(os.Stderr, "Error removing old save file")
+			}
+		}
+	}
+}
+
+// Load loads the saved game, if any. When the save file is damaged, the
+// returned error is a *SaveCorruptedError, and LoadBackup may then be used.
+// The save is consumed once loaded: the game has to be saved again to be
+// continued later.
+func (g *Game) Load() (bool, error) {
+	saveFile, backupFile, consumedFile, err := g.saveFiles()
 	if err != nil {
 		return false, err
 	}
-	saveFile := filepath.Join(dataDir, "save.gob")
 	_, err = os.Stat(saveFile)
 	if err != nil {
+		_, cerr := os.Stat(consumedFile)
+		if _, berr := os.Stat(backupFile); berr == nil && cerr != nil {
+			// interrupted while replacing the save file
+			return true, &SaveCorruptedError{File: saveFile, Err: errors.New("missing file"), Backup: true}
+		}
 		// no save file, new game
 		return false, err
 	}
-	data, err := ioutil.ReadFile(saveFile)
+	err = g.loadFile(saveFile)
 	if err != nil {
+		if _, ok := err.(*SaveVersionError); !ok {
+			_, berr := os.Stat(backupFile)
+			err = &SaveCorruptedError{File: saveFile, Err: err, Backup: berr == nil}
+		}
 		return true, err
 	}
+	return true, g.consumeSave(saveFile)
+}
+
+// LoadBackup loads the backup of the previous save, consuming it.
+func (g *Game) LoadBackup() error {
+	_, backupFile, _, err := g.saveFiles()
+	if err != nil {
+		return err
+	}
+	err = g.loadFile(backupFile)
+	if err != nil {
+		return err
+	}
+	return g.consumeSave(backupFile)
+}
+
+// consumeSave marks a loaded save file as consumed, so that the game cannot
+// be resumed from it once more. The backup is kept until the next save, which
+// replaces it with the consumed save.
+func (g *Game) consumeSave(file string) error {
+	saveFile, _, consumedFile, err := g.saveFiles()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(file, consumedFile)
+	if err != nil {
+		return err
+	}
+	os.Remove(saveFile)
+	return nil
+}
+
+// CanRecover reports whether the last loaded game was neither saved again
+// nor finished, typically because the game crashed.
+func (g *Game) CanRecover() bool {
+	_, _, consumedFile, err := g.saveFiles()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(consumedFile)
+	return err == nil
+}
+
+// Recover loads again the last loaded game, when CanRecover is true. The
+// recovery is recorded in the game.
+func (g *Game) Recover() error {
+	_, _, consumedFile, err := g.saveFiles()
+	if err != nil {
+		return err
+	}
+	err = g.loadFile(consumedFile)
+	if err != nil {
+		return err
+	}
+	g.Recoveries++
+	g.StoryPrint("The game was recovered after it did not end properly.")
+	g.Print("Your game was recovered from its last save.")
+	return nil
+}
+
+func (g *Game) loadFile(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	lg, err := decodeSave(data)
+	if err != nil {
+		if verr, ok := err.(*SaveVersionError); ok {
+			verr.File = file
+		}
+		return err
+	}
+	lg.ui = g.ui
+	*g = *lg
+	g.IndexMonsters()
+	return nil
+}
+
+func decodeSave(data []byte) (*Game, error) {
+	hdr := &saveHeader{}
 	buf := bytes.NewBuffer(data)
 	dec := gob.NewDecoder(buf)
-	var lg game
-	err = dec.Decode(&lg)
+	if bytes.HasPrefix(data, []byte(saveMagic)) {
+		buf.Next(len(saveMagic))
+		err := dec.Decode(hdr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hdr.Format > SaveFormat {
+		return nil, &SaveVersionError{Format: hdr.Format, Version: hdr.Version}
+	}
+	if hdr.Format >= 2 {
+		// the game is encoded separately after the header
+		if crc32.ChecksumIEEE(buf.Bytes()) != hdr.Checksum {
+			return nil, errors.New("bad checksum")
+		}
+		dec = gob.NewDecoder(buf)
+	}
+	g := &Game{}
+	err := dec.Decode(g)
 	if err != nil {
-		return true, err
+		return nil, err
+	}
+	for format := hdr.Format; format < SaveFormat; format++ {
+		migrate, ok := saveMigrations[format]
+		if !ok {
+			return nil, &SaveVersionError{Format: hdr.Format, Version: hdr.Version}
+		}
+		err := migrate(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// convertMonsterIndices gives identifiers to the monsters of a game saved
+// when monster events referred to monsters by their index.
+func (g *Game) convertMonsterIndices() error {
+	for i, m := range g.Monsters {
+		m.ID = i + 1
+	}
+	g.LastMonsterID = len(g.Monsters)
+	for _, ev := range *g.Events {
+		if mev, ok := ev.(*monsterEvent); ok {
+			mev.MonsID = mev.NMons + 1
+			mev.NMons = 0
+		}
 	}
-	*g = lg
-	return true, nil
+	return nil
 }
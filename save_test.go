@@ -0,0 +1,216 @@
+package boohu
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSaveLoadMonsters(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	g, h := newHeadlessGame(11, "")
+	g.noSave = false
+	g.Save()
+	lg := NewGame(h)
+	load, err := lg.Load()
+	if !load || err != nil {
+		t.Fatalf("Loading failed: %v", err)
+	}
+	if len(lg.Monsters) != len(g.Monsters) {
+		t.Fatalf("Got %d monsters instead of %d", len(lg.Monsters), len(g.Monsters))
+	}
+	for _, m := range g.Monsters {
+		lm := lg.MonsterByID(m.ID)
+		if lm == nil || lm.Pos != m.Pos || lm.Kind != m.Kind {
+			t.Errorf("Bad monster for ID %d: %+v", m.ID, lm)
+		}
+	}
+	if lg.LastMonsterID != g.LastMonsterID {
+		t.Errorf("Bad last monster ID: %d", lg.LastMonsterID)
+	}
+}
+
+func TestLoadLegacySave(t *testing.T) {
+	g, _ := newHeadlessGame(12, "")
+	index := map[int]int{}
+	for i, m := range g.Monsters {
+		index[m.ID] = i
+	}
+	// monster events referred to monsters by index before format 1
+	want := []Position{}
+	for _, ev := range *g.Events {
+		if mev, ok := ev.(*monsterEvent); ok {
+			want = append(want, g.MonsterByID(mev.MonsID).Pos)
+			mev.NMons = index[mev.MonsID]
+			mev.MonsID = 0
+		}
+	}
+	for _, m := range g.Monsters {
+		m.ID = 0
+	}
+	g.LastMonsterID = 0
+	var data bytes.Buffer
+	err := gob.NewEncoder(&data).Encode(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lg, err := decodeSave(data.Bytes())
+	if err != nil {
+		t.Fatalf("Decoding legacy save failed: %v", err)
+	}
+	lg.IndexMonsters()
+	i := 0
+	for _, ev := range *lg.Events {
+		if mev, ok := ev.(*monsterEvent); ok {
+			mons := lg.MonsterByID(mev.MonsID)
+			if mons == nil || mons.Pos != want[i] {
+				t.Errorf("Bad monster for event %+v", mev)
+			}
+			i++
+		}
+	}
+}
+
+func TestLoadNewerSave(t *testing.T) {
+	var data bytes.Buffer
+	data.WriteString(saveMagic)
+	enc := gob.NewEncoder(&data)
+	err := enc.Encode(&saveHeader{Format: SaveFormat + 1, Version: "v99"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = decodeSave(data.Bytes())
+	if _, ok := err.(*SaveVersionError); !ok {
+		t.Errorf("Expected a version error, got %v", err)
+	}
+}
+
+func TestLoadDamagedSave(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	g, h := newHeadlessGame(13, "")
+	g.noSave = false
+	g.Save()
+	turn := g.Turn
+	g.Turn += 100
+	g.Save()
+	saveFile, _, _, err := g.saveFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(saveFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	err = ioutil.WriteFile(saveFile, data, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lg := NewGame(h)
+	_, err = lg.Load()
+	cerr, ok := err.(*SaveCorruptedError)
+	if !ok || !cerr.Backup {
+		t.Fatalf("Expected a damaged save with a backup, got %v", err)
+	}
+	err = lg.LoadBackup()
+	if err != nil {
+		t.Fatalf("Loading backup failed: %v", err)
+	}
+	if lg.Turn != turn {
+		t.Errorf("Backup has turn %d instead of %d", lg.Turn, turn)
+	}
+}
+
+func TestLoadConsumesSave(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	g, h := newHeadlessGame(14, "")
+	g.noSave = false
+	g.Save()
+	lg := NewGame(h)
+	if load, err := lg.Load(); !load || err != nil {
+		t.Fatalf("Loading failed: %v", err)
+	}
+	if load, _ := NewGame(h).Load(); load {
+		t.Errorf("The save was loaded twice")
+	}
+	if !lg.CanRecover() {
+		t.Fatalf("The consumed save cannot be recovered")
+	}
+	rg := NewGame(h)
+	if err := rg.Recover(); err != nil {
+		t.Fatalf("Recovering failed: %v", err)
+	}
+	if rg.Recoveries != 1 {
+		t.Errorf("Recovery not recorded")
+	}
+	rg.Save()
+	if rg.CanRecover() {
+		t.Errorf("Consumed save still present after saving")
+	}
+}
+
+func TestSaveBackupAfterLoad(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	g, h := newHeadlessGame(14, "")
+	g.noSave = false
+	for _, turn := range []int{10, 20} {
+		g.Turn = turn
+		g.Save()
+	}
+	lg := NewGame(h)
+	if load, err := lg.Load(); !load || err != nil {
+		t.Fatalf("Loading failed: %v", err)
+	}
+	_, backupFile, _, _ := lg.saveFiles()
+	backup := func() int {
+		data, err := ioutil.ReadFile(backupFile)
+		if err != nil {
+			t.Fatalf("No backup: %v", err)
+		}
+		bg, err := decodeSave(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bg.Turn
+	}
+	if turn := backup(); turn != 10 {
+		t.Errorf("Backup of turn %d after loading, instead of 10", turn)
+	}
+	if load, err := NewGame(h).Load(); load || err == nil {
+		t.Errorf("Consumed save taken for an interrupted one: %v", err)
+	}
+	lg.Turn = 30
+	lg.Save()
+	if turn := backup(); turn != 20 {
+		t.Errorf("Backup of turn %d after saving the loaded game, instead of 20", turn)
+	}
+}
+
+func TestSaveSlots(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	for i, name := range []string{"Alice", "Bob"} {
+		g, _ := newHeadlessGame(int64(15+i), "")
+		g.noSave = false
+		g.Name = name
+		g.Depth = i + 1
+		g.Save()
+	}
+	g := NewGame(nil)
+	slots, err := g.SaveSlots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 2 {
+		t.Fatalf("Got %d slots instead of 2", len(slots))
+	}
+	for _, slot := range slots {
+		if slot.Err != nil || slot.Name == "Alice" && slot.Depth != 1 || slot.Name == "Bob" && slot.Depth != 2 {
+			t.Errorf("Bad slot: %+v", slot)
+		}
+	}
+	g.Name = "Alice"
+	if load, err := g.Load(); !load || err != nil || g.Name != "Alice" || g.Depth != 1 {
+		t.Errorf("Bad loaded game for Alice (error: %v)", err)
+	}
+}
@@ -0,0 +1,227 @@
+package boohu
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// GameStats is the record of a finished game in the statistics file. Items,
+// monsters and aptitudes are recorded by name, so that records of older
+// versions can still be read.
+type GameStats struct {
+	Date      time.Time      `json:"date"`
+	Outcome   string         `json:"outcome"`
+	Depth     int            `json:"depth"`
+	Turns     int            `json:"turns"`
+	Kills     map[string]int `json:"kills"`
+	Used      map[string]int `json:"used"`
+	Evoked    map[string]int `json:"evoked"`
+	Aptitudes []string       `json:"aptitudes"`
+	Killer    string         `json:"killer,omitempty"` // kind of the monster that killed the player
+	Cause     string         `json:"cause,omitempty"`  // cause of death not due to a monster
+}
+
+// GameStats returns the record of the game for the statistics file.
+func (g *Game) GameStats() GameStats {
+	gs := GameStats{
+		Date:      time.Now(),
+		Outcome:   g.Outcome().String(),
+		Depth:     g.Depth,
+		Turns:     g.Turn / 10,
+		Kills:     map[string]int{},
+		Used:      map[string]int{},
+		Evoked:    map[string]int{},
+		Aptitudes: []string{},
+	}
+	for mk, n := range g.KilledMons {
+		gs.Kills[mk.String()] += n
+	}
+	for c, n := range g.Used {
+		gs.Used[c.String()] += n
+	}
+	for r, n := range g.Evoked {
+		gs.Evoked[r.String()] += n
+	}
+	for apt, b := range g.Player.Aptitudes {
+		if b {
+			gs.Aptitudes = append(gs.Aptitudes, apt.String())
+		}
+	}
+	sort.Strings(gs.Aptitudes)
+	if g.Outcome() == Died {
+		if g.KilledBy.Monster != "" {
+			gs.Killer = g.KilledBy.Monster
+		} else {
+			gs.Cause = g.KilledBy.Cause()
+		}
+	}
+	return gs
+}
+
+func (g *Game) statsFile() (string, error) {
+	dataDir, err := g.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dataDir, "stats.jsonl"), nil
+}
+
+// WriteStats appends the record of the finished game to the statistics
+// file, which has one JSON record per line. Games played in wizard mode are
+// not recorded.
+func (g *Game) WriteStats() error {
+	if g.noSave || g.Wizard {
+		return nil
+	}
+	file, err := g.statsFile()
+	if err != nil {
+		return err
+	}
+	return appendGameStats(file, g.GameStats())
+}
+
+func appendGameStats(file string, gs GameStats) error {
+	data, err := json.Marshal(gs)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Stats summarizes the records of the statistics file. Abandoned games are
+// counted apart, and not among the games.
+type Stats struct {
+	Games       int // won or lost games
+	Wins        int
+	Deaths      int
+	Abandoned   int
+	DeathDepths int            // sum of the depths of deaths
+	Killers     map[string]int // deaths by kind of monster
+	Causes      map[string]int // deaths not due to a monster, by cause
+	Kills       map[string]int
+	Used        map[string]int
+	Evoked      map[string]int
+	Aptitudes   map[string]int // games in which each aptitude was gained
+}
+
+// WinRate returns the percentage of won games.
+func (s Stats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return 100 * float64(s.Wins) / float64(s.Games)
+}
+
+// AverageDeathDepth returns the average depth at which the player died.
+func (s Stats) AverageDeathDepth() float64 {
+	if s.Deaths == 0 {
+		return 0
+	}
+	return float64(s.DeathDepths) / float64(s.Deaths)
+}
+
+// Count is a named number, as returned by SortCounts.
+type Count struct {
+	Name  string
+	Count int
+}
+
+// SortCounts returns the counts of m, greatest first.
+func SortCounts(m map[string]int) []Count {
+	counts := []Count{}
+	for name, n := range m {
+		counts = append(counts, Count{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// Stats reads the statistics file and summarizes it.
+func (g *Game) Stats() (Stats, error) {
+	file, err := g.statsFile()
+	if err != nil {
+		return Stats{}, err
+	}
+	return readStats(file)
+}
+
+// readStats summarizes the records of file. Lines that cannot be decoded,
+// such as a line truncated by a crash, are skipped.
+func readStats(file string) (Stats, error) {
+	s := Stats{
+		Killers:   map[string]int{},
+		Causes:    map[string]int{},
+		Kills:     map[string]int{},
+		Used:      map[string]int{},
+		Evoked:    map[string]int{},
+		Aptitudes: map[string]int{},
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var gs GameStats
+		if json.Unmarshal(scanner.Bytes(), &gs) != nil {
+			continue
+		}
+		s.add(gs)
+	}
+	return s, scanner.Err()
+}
+
+func (s *Stats) add(gs GameStats) {
+	switch gs.Outcome {
+	case Won.String():
+		s.Games++
+		s.Wins++
+	case Died.String():
+		s.Games++
+		s.Deaths++
+		s.DeathDepths += gs.Depth
+		if gs.Killer != "" {
+			s.Killers[gs.Killer]++
+		}
+		if gs.Cause != "" {
+			s.Causes[gs.Cause]++
+		}
+	default:
+		// older records have quits as unfinished games
+		s.Abandoned++
+	}
+	for name, n := range gs.Kills {
+		s.Kills[name] += n
+	}
+	for name, n := range gs.Used {
+		s.Used[name] += n
+	}
+	for name, n := range gs.Evoked {
+		s.Evoked[name] += n
+	}
+	for _, apt := range gs.Aptitudes {
+		s.Aptitudes[apt]++
+	}
+}
@@ -0,0 +1,52 @@
+package boohu
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	file := t.TempDir() + "/stats.jsonl"
+	records := []GameStats{
+		{Outcome: "won", Depth: 12, Used: map[string]int{"potion of healing": 2}},
+		{Outcome: "died", Depth: 3, Killer: "ogre", Kills: map[string]int{"goblin": 3}},
+		{Outcome: "died", Depth: 6, Killer: "ogre", Used: map[string]int{"potion of healing": 1}},
+		{Outcome: "died", Depth: 4, Cause: "an explosion"},
+		{Outcome: "abandoned", Depth: 2},
+	}
+	for _, gs := range records {
+		if err := appendGameStats(file, gs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"outcome":"di`)
+	f.Close()
+	s, err := readStats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Games != 4 || s.Wins != 1 || s.Abandoned != 1 || s.AverageDeathDepth() != 13.0/3 {
+		t.Errorf("Bad stats: %+v", s)
+	}
+	if len(s.Killers) != 1 || s.Killers["ogre"] != 2 || s.Causes["an explosion"] != 1 || s.Kills["goblin"] != 3 || s.Used["potion of healing"] != 3 {
+		t.Errorf("Bad counts: %+v", s)
+	}
+	if s.WinRate() != 25 {
+		t.Errorf("Win rate %v instead of 25", s.WinRate())
+	}
+}
+
+func TestSortCounts(t *testing.T) {
+	counts := SortCounts(map[string]int{"goblin": 3, "ogre": 5, "acid mound": 3})
+	names := ""
+	for _, c := range counts {
+		names += c.Name + ","
+	}
+	if names != "ogre,acid mound,goblin," {
+		t.Errorf("Bad order of counts: %s", names)
+	}
+}
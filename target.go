@@ -1,23 +1,23 @@
-package main
+package boohu
 
 import "errors"
 
 type Targetter interface {
-	ComputeHighlight(*game, position)
-	Action(*game, position) error
-	Reachable(*game, position) bool
+	ComputeHighlight(*Game, Position)
+	Action(*Game, Position) error
+	Reachable(*Game, Position) bool
 	Done() bool
 }
 
-type examiner struct {
+type Examiner struct {
 	done bool
 }
 
-func (ex *examiner) ComputeHighlight(g *game, pos position) {
+func (ex *Examiner) ComputeHighlight(g *Game, pos Position) {
 	g.ComputeRayHighlight(pos)
 }
 
-func (ex *examiner) Action(g *game, pos position) error {
+func (ex *Examiner) Action(g *Game, pos Position) error {
 	if g.MonsterInLOS() != nil {
 		return errors.New("You cannot travel while there are monsters in view.")
 	}
@@ -42,11 +42,11 @@ func (ex *examiner) Action(g *game, pos position) error {
 	return errors.New("Invalid destination.")
 }
 
-func (ex *examiner) Reachable(g *game, pos position) bool {
+func (ex *Examiner) Reachable(g *Game, pos Position) bool {
 	return true
 }
 
-func (ex *examiner) Done() bool {
+func (ex *Examiner) Done() bool {
 	return ex.done
 }
 
@@ -57,7 +57,7 @@ type chooser struct {
 	single  bool
 }
 
-func (ch *chooser) ComputeHighlight(g *game, pos position) {
+func (ch *chooser) ComputeHighlight(g *Game, pos Position) {
 	g.ComputeRayHighlight(pos)
 	if !ch.area {
 		return
@@ -68,11 +68,11 @@ func (ch *chooser) ComputeHighlight(g *game, pos position) {
 	}
 }
 
-func (ch *chooser) Reachable(g *game, pos position) bool {
+func (ch *chooser) Reachable(g *Game, pos Position) bool {
 	return g.Player.LOS[pos]
 }
 
-func (ch *chooser) Action(g *game, pos position) error {
+func (ch *chooser) Action(g *Game, pos Position) error {
 	if !ch.Reachable(g, pos) {
 		return errors.New("You cannot target that place.")
 	}
@@ -80,12 +80,13 @@ func (ch *chooser) Action(g *game, pos position) error {
 		return errors.New("Invalid target: too close.")
 	}
 	if c := g.Dungeon.Cell(pos); c.Explored && c.T == FreeCell {
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if (ch.area || ch.single) && !ch.freeWay(g, pos) {
 			return errors.New("Invalid target: there are monsters in the way.")
 		}
 		if mons.Exists() {
 			g.Player.Target = pos
+			g.Player.TargetID = mons.ID
 			ch.done = true
 			return nil
 		}
@@ -94,9 +95,10 @@ func (ch *chooser) Action(g *game, pos position) error {
 		}
 		neighbors := g.Dungeon.FreeNeighbors(pos)
 		for _, npos := range neighbors {
-			mons, _ := g.MonsterAt(npos)
+			mons := g.MonsterAt(npos)
 			if mons.Exists() {
 				g.Player.Target = pos
+				g.Player.TargetID = 0
 				ch.done = true
 				return nil
 			}
@@ -113,11 +115,11 @@ func (ch *chooser) Done() bool {
 	return ch.done
 }
 
-func (ch *chooser) freeWay(g *game, pos position) bool {
+func (ch *chooser) freeWay(g *Game, pos Position) bool {
 	ray := g.Ray(pos)
 	tpos := pos
 	for _, rpos := range ray {
-		mons, _ := g.MonsterAt(rpos)
+		mons := g.MonsterAt(rpos)
 		if !mons.Exists() {
 			continue
 		}
@@ -131,15 +133,15 @@ type wallChooser struct {
 	minDist bool
 }
 
-func (ch *wallChooser) ComputeHighlight(g *game, pos position) {
+func (ch *wallChooser) ComputeHighlight(g *Game, pos Position) {
 	g.ComputeRayHighlight(pos)
 }
 
-func (ch *wallChooser) Reachable(g *game, pos position) bool {
+func (ch *wallChooser) Reachable(g *Game, pos Position) bool {
 	return g.Player.LOS[pos]
 }
 
-func (ch *wallChooser) Action(g *game, pos position) error {
+func (ch *wallChooser) Action(g *Game, pos Position) error {
 	if !ch.Reachable(g, pos) {
 		return errors.New("You cannot target that place.")
 	}
@@ -154,12 +156,13 @@ func (ch *wallChooser) Action(g *game, pos position) error {
 		return errors.New("You cannot target an adjacent wall.")
 	}
 	for _, pos := range ray[1:] {
-		mons, _ := g.MonsterAt(pos)
+		mons := g.MonsterAt(pos)
 		if mons.Exists() {
 			return errors.New("There are monsters in the way.")
 		}
 	}
 	g.Player.Target = pos
+	g.Player.TargetID = 0
 	ch.done = true
 	return nil
 }
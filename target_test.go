@@ -0,0 +1,44 @@
+package boohu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTargetMonsterID(t *testing.T) {
+	g, _ := newHeadlessGame(3, "")
+	var mons, other *Monster
+	for _, m := range g.Monsters {
+		switch {
+		case mons == nil:
+			mons = m
+		case !strings.Contains(m.Kind.String(), mons.Kind.String()) && !strings.Contains(mons.Kind.String(), m.Kind.String()):
+			other = m
+		}
+	}
+	if other == nil {
+		t.Fatal("No monsters of two different kinds")
+	}
+	neighbors := g.Dungeon.FreeNeighbors(g.Player.Pos)
+	if len(neighbors) < 2 {
+		t.Fatal("Not enough free cells around the player")
+	}
+	mons.Pos = neighbors[0]
+	g.ComputeLOS()
+	if err := Javelin.Targetter().Action(g, mons.Pos); err != nil {
+		t.Fatal(err)
+	}
+	if g.Player.TargetID != mons.ID {
+		t.Fatalf("Targeted monster %d instead of %d", g.Player.TargetID, mons.ID)
+	}
+	// the javelin follows the monster, not the targeted cell
+	other.Pos, mons.Pos = mons.Pos, neighbors[1]
+	g.Player.Consumables[Javelin] = 1
+	if err := Javelin.Use(g, &simpleEvent{EAction: PlayerTurn}); err != nil {
+		t.Fatal(err)
+	}
+	msg := g.Log[len(g.Log)-1]
+	if !strings.Contains(msg, mons.Kind.String()) || strings.Contains(msg, other.Kind.String()) {
+		t.Errorf("Javelin not thrown at the targeted %s: %s", mons.Kind, msg)
+	}
+}
@@ -0,0 +1,444 @@
+package boohu
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WizardHelp describes the commands of the wizard console. Names can be
+// abbreviated to any unambiguous part, and positions default to the closest
+// free cell to the player.
+var WizardHelp = []string{
+	"spawn MONSTER [X Y]: create a monster",
+	"band BAND [X Y]: create a band of monsters",
+	"create ITEM [N]: create a potion, projectile, rod or equipable",
+	"aptitude APTITUDE: grant an aptitude",
+	"hp N, mp N: set HP or MP",
+	"status STATUS TURNS: give a status for some turns",
+	"depth N: jump to a new level of depth N",
+	"reveal: reveal the map",
+	"rewind N: go back to the start of the N-th previous turn",
+	"list monsters|bands|items|aptitudes|statuses: list names",
+}
+
+// WizardCommand runs a command of the wizard console during the player's
+// turn ev, and returns a message describing what was done. Commands do not
+// spend the turn, except rewind which ends it (see Rewound).
+func (g *Game) WizardCommand(line string, ev Event) (string, error) {
+	if !g.Wizard {
+		return "", errors.New("Wizard commands are only available in wizard mode.")
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", errors.New("Empty wizard command.")
+	}
+	cmd, args := strings.ToLower(fields[0]), fields[1:]
+	switch cmd {
+	case "spawn":
+		name, pos, err := g.wizardNamePosition(args)
+		if err != nil {
+			return "", err
+		}
+		i, err := wizardMatch("monster", name, wizardMonsterNames())
+		if err != nil {
+			return "", err
+		}
+		mk := monsterKind(i)
+		g.wizardSpawn([]monsterKind{mk}, wizardBandOf(mk), pos)
+		return fmt.Sprintf("Spawned %s.", Indefinite(mk.String(), false)), nil
+	case "band":
+		name, pos, err := g.wizardNamePosition(args)
+		if err != nil {
+			return "", err
+		}
+		i, err := wizardMatch("band", name, wizardBandNames())
+		if err != nil {
+			return "", err
+		}
+		band := monsterBand(i)
+		kinds := g.bandMonsters(MonsBands[band])
+		g.wizardSpawn(kinds, band, pos)
+		return fmt.Sprintf("Spawned the band %s (%d monsters).", wizardBandNames()[i], len(kinds)), nil
+	case "create":
+		return g.wizardCreate(args)
+	case "aptitude":
+		i, err := wizardMatch("aptitude", strings.Join(args, " "), wizardAptitudeNames())
+		if err != nil {
+			return "", err
+		}
+		apt := aptitude(i)
+		if g.Player.Aptitudes[apt] {
+			return "", errors.New("You already have that aptitude.")
+		}
+		g.ApplyAptitude(apt)
+		return "Aptitude granted.", nil
+	case "hp", "mp":
+		n, err := wizardInt(args)
+		if err != nil {
+			return "", err
+		}
+		if n <= 0 {
+			return "", errors.New("The value must be positive.")
+		}
+		if cmd == "hp" {
+			g.Player.HP = n
+		} else {
+			g.Player.MP = n
+		}
+		return fmt.Sprintf("%s set to %d.", strings.ToUpper(cmd), n), nil
+	case "status":
+		if len(args) < 2 {
+			return "", errors.New("Usage: status STATUS TURNS")
+		}
+		turns, err := wizardInt(args[len(args)-1:])
+		if err != nil {
+			return "", err
+		}
+		if turns <= 0 {
+			return "", errors.New("The number of turns must be positive.")
+		}
+		i, err := wizardMatch("status", strings.Join(args[:len(args)-1], " "), wizardStatusNames())
+		if err != nil {
+			return "", err
+		}
+		st := status(i)
+		g.Player.Statuses[st]++
+		g.PushEvent(&simpleEvent{ERank: ev.Rank() + 10*turns, EAction: wizardStatusEnd[st]})
+		return fmt.Sprintf("Status %s given for %d turns.", st, turns), nil
+	case "depth":
+		n, err := wizardInt(args)
+		if err != nil {
+			return "", err
+		}
+		if n < 1 || n > g.MaxDepth() {
+			return "", fmt.Errorf("The depth must be between 1 and %d.", g.MaxDepth())
+		}
+		g.Depth = n
+		g.InitLevel()
+		return fmt.Sprintf("You are now at depth %d.", n), nil
+	case "reveal":
+		for i := range g.Dungeon.Cells {
+			g.Dungeon.SetExplored(g.Dungeon.CellPosition(i))
+		}
+		return "The map is revealed.", nil
+	case "rewind":
+		n, err := wizardInt(args)
+		if err != nil {
+			return "", err
+		}
+		err = g.Rewind(n)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Rewound %d turns, back to turn %d.", n, g.Turn/10), nil
+	case "list":
+		if len(args) != 1 {
+			return "", errors.New("Usage: list monsters|bands|items|aptitudes|statuses")
+		}
+		lists := map[string][]string{
+			"monsters":  wizardMonsterNames(),
+			"bands":     wizardBandNames(),
+			"items":     wizardItemNames(),
+			"aptitudes": wizardAptitudeNames(),
+			"statuses":  wizardStatusNames(),
+		}
+		names, ok := lists[strings.ToLower(args[0])]
+		if !ok {
+			return "", fmt.Errorf("Nothing to list called %s.", args[0])
+		}
+		return strings.Join(names, ", "), nil
+	case "help":
+		return strings.Join(WizardHelp, "; "), nil
+	default:
+		return "", fmt.Errorf("Unknown wizard command: %s (try help).", fields[0])
+	}
+}
+
+// wizardMatch returns the index of the name of names matching query: a name
+// equal to it, or else the only one containing it, ignoring case.
+func wizardMatch(what, query string, names []string) (int, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return 0, fmt.Errorf("Which %s?", what)
+	}
+	matches := []int{}
+	for i, name := range names {
+		name = strings.ToLower(name)
+		if name == query {
+			return i, nil
+		}
+		if strings.Contains(name, query) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, fmt.Errorf("No %s matches %q.", what, query)
+	case 1:
+		return matches[0], nil
+	default:
+		candidates := []string{}
+		for _, i := range matches {
+			candidates = append(candidates, names[i])
+		}
+		return 0, fmt.Errorf("%q could be: %s.", query, strings.Join(candidates, ", "))
+	}
+}
+
+func wizardInt(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.New("Expected a number.")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("Not a number: %s.", args[0])
+	}
+	return n, nil
+}
+
+// wizardNamePosition splits the arguments of spawn and band into a name and
+// a free position, given by the two last arguments if they are numbers.
+func (g *Game) wizardNamePosition(args []string) (string, Position, error) {
+	pos := g.Player.Pos
+	if n := len(args); n >= 3 {
+		x, errx := strconv.Atoi(args[n-2])
+		y, erry := strconv.Atoi(args[n-1])
+		if errx == nil && erry == nil {
+			pos = Position{X: x, Y: y}
+			if !g.Dungeon.Valid(pos) {
+				return "", pos, fmt.Errorf("Position %d,%d is out of the map.", x, y)
+			}
+			args = args[:n-2]
+		}
+	}
+	return strings.Join(args, " "), pos, nil
+}
+
+// wizardFreeCell returns the free cell without monster and player closest to
+// pos.
+func (g *Game) wizardFreeCell(pos Position) (Position, bool) {
+	return g.wizardClosestCell(pos, func(pos Position) bool {
+		return pos != g.Player.Pos && !g.MonsterAt(pos).Exists()
+	})
+}
+
+// wizardGroundCell returns the free cell without any object on the ground
+// closest to pos.
+func (g *Game) wizardGroundCell(pos Position) (Position, bool) {
+	return g.wizardClosestCell(pos, func(pos Position) bool {
+		_, eq := g.Equipables[pos]
+		_, rod := g.Rods[pos]
+		return !eq && !rod && g.Collectables[pos] == nil && g.Gold[pos] == 0 && !g.Stairs[pos]
+	})
+}
+
+// wizardClosestCell returns the free cell satisfying ok closest to pos.
+func (g *Game) wizardClosestCell(pos Position, ok func(Position) bool) (Position, bool) {
+	seen := map[Position]bool{pos: true}
+	queue := []Position{pos}
+	for len(queue) > 0 {
+		pos = queue[0]
+		queue = queue[1:]
+		if g.Dungeon.Cell(pos).T == FreeCell && ok(pos) {
+			return pos, true
+		}
+		for _, npos := range g.Dungeon.Neighbors(pos) {
+			if !seen[npos] {
+				seen[npos] = true
+				queue = append(queue, npos)
+			}
+		}
+	}
+	return pos, false
+}
+
+// wizardSpawn adds monsters of a new band around pos, which are aware of
+// the player.
+func (g *Game) wizardSpawn(kinds []monsterKind, band monsterBand, pos Position) {
+	g.Bands = append(g.Bands, band)
+	for _, mk := range kinds {
+		free, ok := g.wizardFreeCell(pos)
+		if !ok {
+			return
+		}
+		mons := &Monster{Kind: mk}
+		mons.Init(g)
+		mons.Pos = free
+		mons.Band = len(g.Bands) - 1
+		g.AddMonster(mons)
+		g.PushEvent(&monsterEvent{ERank: g.Turn + 1, EAction: MonsterTurn, MonsID: mons.ID})
+		g.PushEvent(&monsterEvent{ERank: g.Turn + 50, EAction: HealMonster, MonsID: mons.ID})
+		pos = free
+	}
+	g.MakeMonstersAware()
+}
+
+// wizardBandOf returns the band of a spawned monster: its lone band if it
+// has one, or a band it belongs to.
+func wizardBandOf(mk monsterKind) monsterBand {
+	for band, data := range MonsBands {
+		if !data.band && data.monster == mk {
+			return monsterBand(band)
+		}
+	}
+	for band, data := range MonsBands {
+		if _, ok := data.distribution[mk]; ok {
+			return monsterBand(band)
+		}
+	}
+	return LoneGoblin
+}
+
+func (g *Game) wizardCreate(args []string) (string, error) {
+	n := 1
+	if len(args) >= 2 {
+		if count, err := strconv.Atoi(args[len(args)-1]); err == nil {
+			if count <= 0 {
+				return "", errors.New("The number must be positive.")
+			}
+			n = count
+			args = args[:len(args)-1]
+		}
+	}
+	i, err := wizardMatch("item", strings.Join(args, " "), wizardItemNames())
+	if err != nil {
+		return "", err
+	}
+	switch item := wizardItems()[i].(type) {
+	case consumable:
+		g.Player.Consumables[item] += n
+		if n == 1 {
+			return fmt.Sprintf("You now have %s.", Indefinite(item.String(), false)), nil
+		}
+		return fmt.Sprintf("You now have %d more %s.", n, item.Plural()), nil
+	case rod:
+		if g.Player.Rods[item] != nil {
+			g.Player.Rods[item].Charge = item.MaxCharge()
+			return fmt.Sprintf("Your %s is recharged.", item), nil
+		}
+		g.Player.Rods[item] = &rodProps{Charge: item.MaxCharge()}
+		return fmt.Sprintf("You now have %s.", Indefinite(item.String(), false)), nil
+	case equipable:
+		pos, ok := g.wizardGroundCell(g.Player.Pos)
+		if !ok {
+			return "", errors.New("There is no room on the ground.")
+		}
+		g.Equipables[pos] = item
+		if pos != g.Player.Pos {
+			return fmt.Sprintf("There is %s on the ground nearby.", Indefinite(item.String(), false)), nil
+		}
+		return fmt.Sprintf("There is %s on the ground.", Indefinite(item.String(), false)), nil
+	}
+	return "", errors.New("Cannot create that item.")
+}
+
+func wizardMonsterNames() []string {
+	names := []string{}
+	for mk := range MonsData {
+		names = append(names, monsterKind(mk).String())
+	}
+	return names
+}
+
+// wizardBands names the bands of monsters that are not lone monsters.
+var wizardBands = map[monsterBand]string{
+	BandGoblins:             "goblins",
+	BandGoblinsWithWarriors: "goblins with warriors",
+	BandGoblinWarriors:      "goblin warriors",
+	BandHounds:              "hounds",
+	BandYacks:               "yacks",
+	BandSpiders:             "spiders",
+	BandBlinkingFrogs:       "blinking frogs",
+	BandGiantBees:           "giant bees",
+	BandSkeletonWarrior:     "skeleton warriors",
+	UBandWorms:              "unique worms",
+	UBandGoblinsEasy:        "unique easy goblins",
+	UBandFrogs:              "unique frogs",
+	UBandOgres:              "unique ogres",
+	UBandGoblins:            "unique goblins",
+	UBandBeeYacks:           "unique bees and yacks",
+	UHydras:                 "unique hydras",
+	ULich:                   "unique lich",
+	UBrizzias:               "unique brizzias",
+	UAcidMounds:             "unique acid mounds",
+	UDragon:                 "unique dragon",
+}
+
+func wizardBandNames() []string {
+	names := []string{}
+	for band, data := range MonsBands {
+		if name, ok := wizardBands[monsterBand(band)]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, "lone "+data.monster.String())
+		}
+	}
+	return names
+}
+
+// wizardItems returns the items that can be created: the implemented
+// consumables and rods, and the equipables.
+func wizardItems() []interface{} {
+	items := []interface{}{}
+	for p := HealWoundsPotion; p <= MagicPotion; p++ {
+		items = append(items, p)
+	}
+	for p := Javelin; p <= ConfusingDart; p++ {
+		items = append(items, p)
+	}
+	for r := RodDigging; r <= RodShatter; r++ {
+		items = append(items, r)
+	}
+	for ar := Robe; ar <= PlateArmour; ar++ {
+		items = append(items, ar)
+	}
+	for wp := Dagger; wp <= DoubleSword; wp++ {
+		items = append(items, wp)
+	}
+	for sh := Buckler; sh <= Shield; sh++ {
+		items = append(items, sh)
+	}
+	return items
+}
+
+func wizardItemNames() []string {
+	names := []string{}
+	for _, item := range wizardItems() {
+		names = append(names, item.(fmt.Stringer).String())
+	}
+	return names
+}
+
+func wizardAptitudeNames() []string {
+	names := []string{}
+	for apt := AptAccurate; apt <= AptStrong; apt++ {
+		names = append(names, apt.String())
+	}
+	return names
+}
+
+// wizardStatusEnd are the events that end the statuses given with the
+// wizard console.
+var wizardStatusEnd = []simpleAction{
+	StatusBerserk:        BerserkEnd,
+	StatusSlow:           SlowEnd,
+	StatusExhausted:      ExhaustionEnd,
+	StatusSwift:          HasteEnd,
+	StatusAgile:          EvasionEnd,
+	StatusLignification:  LignificationEnd,
+	StatusConfusion:      ConfusionEnd,
+	StatusTele:           Teleportation,
+	StatusNausea:         NauseaEnd,
+	StatusDisabledShield: DisabledShieldEnd,
+	StatusCorrosion:      CorrosionEnd,
+}
+
+func wizardStatusNames() []string {
+	names := []string{}
+	for st := range wizardStatusEnd {
+		names = append(names, status(st).String())
+	}
+	return names
+}
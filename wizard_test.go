@@ -0,0 +1,87 @@
+package boohu
+
+import "testing"
+
+func TestWizardCommand(t *testing.T) {
+	g, _ := newHeadlessGame(7, "")
+	ev := &simpleEvent{ERank: g.Turn, EAction: PlayerTurn}
+	if _, err := g.WizardCommand("reveal", ev); err == nil {
+		t.Errorf("Wizard command accepted outside of wizard mode")
+	}
+	g.Wizard = true
+	nmons := len(g.Monsters)
+	for _, cmd := range []string{"spawn ogre", "band goblins with", "create heal 3", "create plate", "create blink", "aptitude fast", "hp 99", "status lignif 5", "depth 4", "reveal"} {
+		if _, err := g.WizardCommand(cmd, ev); err != nil {
+			t.Errorf("%s: %v", cmd, err)
+		}
+		if cmd == "band goblins with" && len(g.Monsters) < nmons+4 {
+			t.Errorf("Spawned %d monsters instead of at least 4", len(g.Monsters)-nmons)
+		}
+	}
+	if g.Player.Consumables[HealWoundsPotion] < 3 || g.Player.Rods[RodBlink] == nil || !g.Player.Aptitudes[AptFast] {
+		t.Errorf("Items or aptitude not created")
+	}
+	if g.Player.HP != 99 || g.Player.Statuses[StatusLignification] != 1 || g.Depth != 4 {
+		t.Errorf("Bad player state: HP %d, depth %d", g.Player.HP, g.Depth)
+	}
+	for _, c := range g.Dungeon.Cells {
+		if !c.Explored {
+			t.Fatalf("Map not revealed")
+		}
+	}
+	// items already on the ground are kept
+	neq := len(g.Equipables)
+	for i := 0; i < 2; i++ {
+		if _, err := g.WizardCommand("create plate", ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(g.Equipables) != neq+2 {
+		t.Errorf("Got %d equipables on the ground instead of %d", len(g.Equipables), neq+2)
+	}
+	for _, cmd := range []string{"spawn o", "create potion of nothing", "depth 0", "jump"} {
+		if _, err := g.WizardCommand(cmd, ev); err == nil {
+			t.Errorf("%s: no error", cmd)
+		}
+	}
+}
+
+func TestRewind(t *testing.T) {
+	g, h := newHeadlessGame(11, "....")
+	g.Wizard = true
+	g.EventLoop()
+	if err := g.Rewind(5); err == nil {
+		t.Errorf("Rewound more turns than played")
+	}
+	sn := g.snapshots[len(g.snapshots)-3]
+	if sg, err := decodeSave(sn.data); err != nil || sg.Log != nil || sg.Story != nil || sg.Inputs != nil {
+		t.Errorf("Snapshot with log, story or inputs (error: %v)", err)
+	}
+	inputs := len(g.Inputs)
+	g.RecordInput(ReplayInput{Kind: KeyInput, Ch: '.'})
+	if err := g.Rewind(2); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Rewound() {
+		t.Errorf("Rewind not reported")
+	}
+	if len(g.Log) != len(sn.log) || len(g.Story) != len(sn.story) || len(g.Inputs) != inputs+1 {
+		t.Errorf("Bad log, story or inputs after rewinding")
+	}
+	g.Inputs = g.Inputs[:inputs]
+	h.script = append(h.script, []rune("ll")...)
+	g.Quit = false
+	g.EventLoop()
+	// the same game played without rewinding
+	c, _ := newHeadlessGame(11, "..ll")
+	c.Wizard = true
+	c.EventLoop()
+	if g.Turn != c.Turn || g.Player.Pos != c.Player.Pos || g.Rand != c.Rand {
+		t.Errorf("Rewound game at turn %d, position %v, rng %v, instead of %d, %v, %v",
+			g.Turn, g.Player.Pos, g.Rand, c.Turn, c.Player.Pos, c.Rand)
+	}
+	if g.EventSeq != c.EventSeq || g.Events.Len() != c.Events.Len() {
+		t.Errorf("Rewound game with %d events (seq %d) instead of %d (seq %d)",
+			g.Events.Len(), g.EventSeq, c.Events.Len(), c.EventSeq)
+	}
+}